@@ -0,0 +1,54 @@
+package crypt
+
+// Allocator provides memory for Reader helpers that return newly allocated data.
+// The contents of returned buffers may be arbitrary, since helpers overwrite them.
+type Allocator interface {
+	Alloc(n int) []byte
+}
+
+// DefaultArenaChunk is the default chunk size of Arena.
+const DefaultArenaChunk = 64 << 10
+
+// NewArena creates a new arena allocator with a given chunk size.
+// If size is zero, DefaultArenaChunk is used.
+func NewArena(chunk int) *Arena {
+	if chunk <= 0 {
+		chunk = DefaultArenaChunk
+	}
+	return &Arena{chunk: chunk}
+}
+
+// Arena is an Allocator that hands out parts of large chunks of memory. Calling Reset makes the memory reusable,
+// which keeps GC pressure low when the same arena is used to parse many files.
+//
+// All buffers returned by Alloc become invalid after Reset. Arena is not safe for concurrent use.
+type Arena struct {
+	chunk  int
+	chunks [][]byte
+	cur    int
+	off    int
+}
+
+// Alloc implements Allocator. Requests larger than the chunk size are allocated separately and are not reused.
+func (a *Arena) Alloc(n int) []byte {
+	if n > a.chunk {
+		return make([]byte, n)
+	}
+	for a.cur < len(a.chunks) && a.off+n > len(a.chunks[a.cur]) {
+		a.cur++
+		a.off = 0
+	}
+	if a.cur == len(a.chunks) {
+		a.chunks = append(a.chunks, make([]byte, a.chunk))
+		a.off = 0
+	}
+	b := a.chunks[a.cur][a.off : a.off+n : a.off+n]
+	a.off += n
+	return b
+}
+
+// Reset makes all the memory available for reuse.
+func (a *Arena) Reset() {
+	a.cur = 0
+	a.off = 0
+}
@@ -0,0 +1,36 @@
+package crypt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArena(t *testing.T) {
+	a := NewArena(16)
+	b1 := a.Alloc(10)
+	b2 := a.Alloc(6)
+	require.Len(t, b1, 10)
+	require.Equal(t, 10, cap(b1))
+	require.Len(t, b2, 6)
+	b3 := a.Alloc(4)
+	require.Len(t, b3, 4)
+	require.Len(t, a.chunks, 2)
+	big := a.Alloc(100)
+	require.Len(t, big, 100)
+	require.Len(t, a.chunks, 2)
+
+	a.Reset()
+	b4 := a.Alloc(16)
+	require.Same(t, &b1[0], &b4[0])
+	a.Alloc(3)
+	require.Len(t, a.chunks, 2)
+
+	r, err := NewReader(bytes.NewReader(nil), NoKey, WithAllocator(a))
+	require.NoError(t, err)
+	a.Reset()
+	buf, err := r.alloc(8)
+	require.NoError(t, err)
+	require.Same(t, &b1[0], &buf[0])
+}
@@ -0,0 +1,55 @@
+package crypt
+
+import (
+	"io"
+
+	"golang.org/x/crypto/blowfish"
+)
+
+// NewReaderAt creates an io.ReaderAt that decodes data at arbitrary offsets of r.
+//
+// Unlike Reader, it has no internal state and each call decodes the blocks independently.
+// Thus, it is safe for concurrent use, as long as r is.
+func NewReaderAt(r io.ReaderAt, key Key) (io.ReaderAt, error) {
+	c, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &readerAt{r: r, c: c}, nil
+}
+
+type readerAt struct {
+	r io.ReaderAt
+	c *blowfish.Cipher
+}
+
+// ReadAt implements io.ReaderAt.
+func (r *readerAt) ReadAt(p []byte, off int64) (int, error) {
+	return readAt(r.r, r.c, p, off)
+}
+
+// NewWriterAt creates an io.WriterAt that encodes and writes data at arbitrary offsets of w.
+//
+// Each call encodes the blocks independently, which allows assembling files out of order.
+// Partial blocks are read, modified and written back if w implements io.ReaderAt,
+// otherwise both the offset and the size of each write must be multiples of Block.
+// It is safe for concurrent use, as long as w is and the writes don't touch the same blocks.
+func NewWriterAt(w io.WriterAt, key Key) (io.WriterAt, error) {
+	c, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	ra, _ := w.(io.ReaderAt)
+	return &writerAt{w: w, r: ra, c: c}, nil
+}
+
+type writerAt struct {
+	w io.WriterAt
+	r io.ReaderAt
+	c *blowfish.Cipher
+}
+
+// WriteAt implements io.WriterAt.
+func (w *writerAt) WriteAt(p []byte, off int64) (int, error) {
+	return writeAt(w.r, w.w, w.c, p, off)
+}
@@ -0,0 +1,76 @@
+package crypt
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReaderAt(t *testing.T) {
+	plain := bytes.Repeat([]byte("0123456789"), 100)
+	enc, err := EncodePadded(MapKey, append([]byte{}, plain...))
+	require.NoError(t, err)
+
+	ra, err := NewReaderAt(bytes.NewReader(enc), MapKey)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for off := i; off+13 <= len(plain); off += 29 {
+				b := make([]byte, 13)
+				n, err := ra.ReadAt(b, int64(off))
+				if !(n == len(b) && err == nil && bytes.Equal(plain[off:off+13], b)) {
+					t.Errorf("unexpected read at %d: %q, %v", off, b[:n], err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	_, err = NewReaderAt(bytes.NewReader(enc), Key(100))
+	require.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestWriterAt(t *testing.T) {
+	buf := &bufferAt{}
+	wa, err := NewWriterAt(buf, MapKey)
+	require.NoError(t, err)
+	_, err = wa.WriteAt([]byte("index!!!"), 2*Block)
+	require.NoError(t, err)
+	_, err = wa.WriteAt([]byte("section1section2"), 0)
+	require.NoError(t, err)
+	_, err = wa.WriteAt([]byte("xx"), 3)
+	require.ErrorIs(t, err, ErrUnaligned)
+
+	data := append([]byte{}, buf.buf...)
+	require.NoError(t, Decode(data, MapKey))
+	require.Equal(t, "section1section2index!!!", string(data))
+
+	f, err := os.CreateTemp(t.TempDir(), "crypt-at-")
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.Write(buf.buf)
+	require.NoError(t, err)
+
+	wa, err = NewWriterAt(f, MapKey)
+	require.NoError(t, err)
+	n, err := wa.WriteAt([]byte("ONE-S"), 4)
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+	_, err = wa.WriteAt([]byte("tail"), 3*Block+2)
+	require.NoError(t, err)
+
+	ra, err := NewReaderAt(f, MapKey)
+	require.NoError(t, err)
+	out := make([]byte, 4*Block)
+	_, err = ra.ReadAt(out, 0)
+	require.NoError(t, err)
+	require.Equal(t, "sectONE-Section2index!!!\x00\x00tail\x00\x00", string(out))
+}
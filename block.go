@@ -0,0 +1,43 @@
+package crypt
+
+// EncodedSize returns the size of n bytes of data after encoding, aligned to the block size.
+func EncodedSize(n int64) int64 {
+	return Blocks(n) * Block
+}
+
+// Blocks returns the number of blocks needed to store n bytes of data.
+func Blocks(n int64) int64 {
+	if n <= 0 {
+		return 0
+	}
+	return (n + Block - 1) / Block
+}
+
+// Padding returns the number of padding bytes added when encoding n bytes of data, see EncodedSize.
+func Padding(n int64) int {
+	if n <= 0 {
+		return 0
+	}
+	return int(EncodedSize(n) - n)
+}
+
+// RoundUpToBlock returns the size of n bytes of data, aligned to the block size.
+//
+// Deprecated: Use EncodedSize.
+func RoundUpToBlock(n int64) int64 {
+	return EncodedSize(n)
+}
+
+// BlockCount returns the number of blocks needed to store n bytes of data.
+//
+// Deprecated: Use Blocks.
+func BlockCount(n int64) int64 {
+	return Blocks(n)
+}
+
+// PadLen returns the number of padding bytes needed to align n bytes of data to the block size.
+//
+// Deprecated: Use Padding.
+func PadLen(n int64) int64 {
+	return int64(Padding(n))
+}
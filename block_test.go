@@ -0,0 +1,28 @@
+package crypt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockMath(t *testing.T) {
+	for _, c := range []struct {
+		n, size, blocks, pad int64
+	}{
+		{-1, 0, 0, 0},
+		{0, 0, 0, 0},
+		{1, 8, 1, 7},
+		{7, 8, 1, 1},
+		{8, 8, 1, 0},
+		{9, 16, 2, 7},
+		{16, 16, 2, 0},
+	} {
+		require.Equal(t, c.size, EncodedSize(c.n), "%d", c.n)
+		require.Equal(t, c.blocks, Blocks(c.n), "%d", c.n)
+		require.Equal(t, int(c.pad), Padding(c.n), "%d", c.n)
+		require.Equal(t, c.size, RoundUpToBlock(c.n), "%d", c.n)
+		require.Equal(t, c.blocks, BlockCount(c.n), "%d", c.n)
+		require.Equal(t, c.pad, PadLen(c.n), "%d", c.n)
+	}
+}
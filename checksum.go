@@ -0,0 +1,108 @@
+package crypt
+
+import (
+	"hash"
+	"hash/adler32"
+)
+
+// CRC16IBM is the reversed polynomial of CRC-16/ARC (also known as CRC-16/IBM).
+const CRC16IBM = uint16(0xA001)
+
+// CRC16Table is a 256-word table representing the polynomial for efficient CRC16 processing.
+type CRC16Table [256]uint16
+
+var crc16IBMTable = NewCRC16Table(CRC16IBM)
+
+// NewCRC16Table creates a CRC16 table for a given polynomial (in reversed form, same as hash/crc32).
+func NewCRC16Table(poly uint16) *CRC16Table {
+	t := new(CRC16Table)
+	for i := range t {
+		crc := uint16(i)
+		for j := 0; j < 8; j++ {
+			if crc&1 == 1 {
+				crc = (crc >> 1) ^ poly
+			} else {
+				crc >>= 1
+			}
+		}
+		t[i] = crc
+	}
+	return t
+}
+
+// UpdateCRC16 updates CRC-16/ARC checksum, which starts from zero.
+func UpdateCRC16(crc uint16, p []byte) uint16 {
+	return UpdateCRC16Table(crc, crc16IBMTable, p)
+}
+
+// UpdateCRC16Table is the same as UpdateCRC16, but uses a custom table, see NewCRC16Table.
+// The initial value and the final invert, if any, are handled by the caller.
+func UpdateCRC16Table(crc uint16, tab *CRC16Table, p []byte) uint16 {
+	for _, v := range p {
+		crc = tab[byte(crc)^v] ^ (crc >> 8)
+	}
+	return crc
+}
+
+// NewCRC16 creates a new hash.Hash computing CRC-16/ARC checksum. The sum is in big-endian order.
+func NewCRC16() hash.Hash {
+	return NewCRC16WithTable(crc16IBMTable)
+}
+
+// NewCRC16WithTable is the same as NewCRC16, but uses a custom table, see NewCRC16Table.
+func NewCRC16WithTable(tab *CRC16Table) hash.Hash {
+	return &crc16{tab: tab}
+}
+
+type crc16 struct {
+	tab *CRC16Table
+	crc uint16
+}
+
+func (h *crc16) Size() int { return 2 }
+
+func (h *crc16) BlockSize() int { return 1 }
+
+func (h *crc16) Reset() { h.crc = 0 }
+
+func (h *crc16) Write(p []byte) (int, error) {
+	h.crc = UpdateCRC16Table(h.crc, h.tab, p)
+	return len(p), nil
+}
+
+func (h *crc16) Sum(in []byte) []byte {
+	return append(in, byte(h.crc>>8), byte(h.crc))
+}
+
+// ZeroAdler32 is an initial value for UpdateAdler32 function.
+const ZeroAdler32 = uint32(1)
+
+const (
+	adlerMod = 65521
+	// adlerMax is the largest n such that 255*n*(n+1)/2 + (n+1)*(adlerMod-1) fits into uint32.
+	adlerMax = 5552
+)
+
+// UpdateAdler32 updates Adler-32 checksum, same as computed by hash/adler32.
+func UpdateAdler32(adler uint32, p []byte) uint32 {
+	s1, s2 := adler&0xffff, adler>>16
+	for len(p) > 0 {
+		var q []byte
+		if len(p) > adlerMax {
+			p, q = p[:adlerMax], p[adlerMax:]
+		}
+		for _, v := range p {
+			s1 += uint32(v)
+			s2 += s1
+		}
+		s1 %= adlerMod
+		s2 %= adlerMod
+		p = q
+	}
+	return s2<<16 | s1
+}
+
+// NewAdler32 creates a new hash.Hash32 computing Adler-32 checksum.
+func NewAdler32() hash.Hash32 {
+	return adler32.New()
+}
@@ -0,0 +1,32 @@
+package crypt
+
+import (
+	"bytes"
+	"hash/adler32"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCRC16(t *testing.T) {
+	// check value of CRC-16/ARC
+	require.Equal(t, uint16(0xBB3D), UpdateCRC16(0, []byte("123456789")))
+	require.Equal(t, uint16(0xBB3D), UpdateCRC16(UpdateCRC16(0, []byte("1234")), []byte("56789")))
+
+	h := NewCRC16()
+	_, _ = h.Write([]byte("123456789"))
+	require.Equal(t, []byte{0xBB, 0x3D}, h.Sum(nil))
+	h.Reset()
+	require.Equal(t, []byte{0, 0}, h.Sum(nil))
+}
+
+func TestAdler32(t *testing.T) {
+	data := bytes.Repeat([]byte{0xff, 0x01, 0x80}, 10000)
+	exp := adler32.Checksum(data)
+	require.Equal(t, exp, UpdateAdler32(ZeroAdler32, data))
+	require.Equal(t, exp, UpdateAdler32(UpdateAdler32(ZeroAdler32, data[:7]), data[7:]))
+
+	h := NewAdler32()
+	_, _ = h.Write(data)
+	require.Equal(t, exp, h.Sum32())
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// batch holds flags of the batch mode, which processes all files matching a pattern.
+type batch struct {
+	glob    string
+	out     string
+	workers int
+}
+
+func (b *batch) flags(fs *flag.FlagSet) {
+	fs.StringVar(&b.glob, "glob", "", "process all files in the directories matching the pattern (for example, '*.map')")
+	fs.StringVar(&b.out, "out", "", "output directory for the batch mode (default: convert files in place)")
+	fs.IntVar(&b.workers, "j", runtime.NumCPU(), "number of parallel workers in the batch mode")
+}
+
+// files returns all files in dirs matching the pattern, relative to the corresponding directory.
+func (b *batch) files(dirs []string) ([][2]string, error) {
+	if _, err := filepath.Match(b.glob, ""); err != nil {
+		return nil, err
+	}
+	var files [][2]string
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if ok, _ := filepath.Match(b.glob, d.Name()); !ok {
+				return nil
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, [2]string{dir, rel})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// runBatch calls fnc for all files matching the pattern in parallel and prints a summary.
+func (c *cli) runBatch(b *batch, dirs []string, fnc func(in, out string) error) error {
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	files, err := b.files(dirs)
+	if err != nil {
+		return err
+	}
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed int
+		jobs   = make(chan [2]string)
+	)
+	for i := 0; i < max(1, b.workers); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range jobs {
+				in := filepath.Join(f[0], f[1])
+				out := in
+				var err error
+				if b.out != "" {
+					out = filepath.Join(b.out, f[1])
+					err = os.MkdirAll(filepath.Dir(out), 0755)
+				}
+				if err == nil {
+					err = fnc(in, out)
+				}
+				if err != nil {
+					mu.Lock()
+					failed++
+					fmt.Fprintf(c.stderr, "%s: %v\n", in, err)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, f := range files {
+		jobs <- f
+	}
+	close(jobs)
+	wg.Wait()
+	fmt.Fprintf(c.stdout, "processed %d files, %d failed\n", len(files), failed)
+	if failed != 0 {
+		return fmt.Errorf("%d of %d files failed", failed, len(files))
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+
+	crypt "github.com/opennox/noxcrypt"
+)
+
+func init() {
+	register(&command{
+		name:  "crc",
+		args:  "[-key name] [-std] [-raw] <file>...",
+		short: "print the CRC of decrypted files",
+		run:   (*cli).crc,
+	})
+}
+
+func (c *cli) crc(args []string) error {
+	fs := c.flags("crc")
+	var key keyFlag
+	fs.Var(&key, "key", "key name or index (default: detected by the file extension)")
+	std := fs.Bool("std", false, "print the standard CRC32 (IEEE) as well")
+	raw := fs.Bool("raw", false, "checksum the encrypted data as-is, without decrypting it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errUsage
+	}
+	for _, path := range fs.Args() {
+		k := crypt.KeyNone
+		if !*raw {
+			var err error
+			if k, err = key.resolve(path); err != nil {
+				return err
+			}
+		}
+		sum, stdSum, err := fileCRC(path, k)
+		if err != nil {
+			return err
+		}
+		if *std {
+			fmt.Fprintf(c.stdout, "%08x %08x  %s\n", sum, stdSum, path)
+		} else {
+			fmt.Fprintf(c.stdout, "%08x  %s\n", sum, path)
+		}
+	}
+	return nil
+}
+
+// fileCRC returns the Nox CRC and the standard CRC32 of the file decrypted with a given key.
+func fileCRC(path string, key crypt.Key) (uint32, uint32, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	r, err := crypt.NewReader(f, key)
+	if err != nil {
+		return 0, 0, err
+	}
+	h, std := crypt.NewCRC(), crc32.NewIEEE()
+	if _, err = io.Copy(io.MultiWriter(h, std), r); err != nil {
+		return 0, 0, err
+	}
+	return h.Sum32(), std.Sum32(), nil
+}
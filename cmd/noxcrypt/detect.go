@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	crypt "github.com/opennox/noxcrypt"
+)
+
+func init() {
+	register(&command{
+		name:  "detect",
+		args:  "<file>...",
+		short: "detect the key and format of files",
+		run:   (*cli).detect,
+	})
+}
+
+// keyFormats describes which files are encrypted by each known key.
+var keyFormats = map[crypt.Key]string{
+	crypt.KeyNone:     "not encrypted",
+	crypt.KeySoundSet: "sound set (soundset.bin)",
+	crypt.KeyThing:    "thing database (thing.bin)",
+	crypt.KeyGameData: "game data (gamedata.bin)",
+	crypt.KeyModifier: "modifiers (modifier.bin)",
+	crypt.KeyMap:      "map (.map)",
+	crypt.KeyMonster:  "monster database (monster.bin)",
+	crypt.KeySave:     "player or save file (.plr, .sav)",
+}
+
+func (c *cli) detect(args []string) error {
+	fs := c.flags("detect")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errUsage
+	}
+	failed := 0
+	for _, path := range fs.Args() {
+		k, err := detectFile(path)
+		if errors.Is(err, crypt.ErrUnknownFile) {
+			fmt.Fprintf(c.stdout, "%s: unknown\n", path)
+			failed++
+			continue
+		} else if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "%s: %s, %s", path, k, keyFormats[k])
+		if ext, ok := crypt.KeyForFile(path); ok && ext != k {
+			fmt.Fprintf(c.stdout, " (extension suggests %s)", ext)
+		}
+		fmt.Fprintln(c.stdout)
+	}
+	if failed != 0 {
+		return fmt.Errorf("cannot detect the key for %d file(s)", failed)
+	}
+	return nil
+}
+
+func detectFile(path string) (crypt.Key, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return crypt.KeyNone, err
+	}
+	defer f.Close()
+	return crypt.DetectKey(f)
+}
@@ -0,0 +1,185 @@
+// Command noxcrypt encrypts, decrypts and inspects Nox game files.
+//
+// Usage:
+//
+//	noxcrypt <command> [flags] <args>
+//
+// Run "noxcrypt help" for the list of commands.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	crypt "github.com/opennox/noxcrypt"
+)
+
+type command struct {
+	name  string
+	args  string
+	short string
+	run   func(c *cli, args []string) error
+}
+
+var commands []*command
+
+func register(c *command) {
+	commands = append(commands, c)
+}
+
+// cli holds the environment of a single command invocation.
+type cli struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// errUsage is returned by commands when arguments are invalid. Usage is printed by the caller.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	c := &cli{stdout: stdout, stderr: stderr}
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		c.usage()
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		err := cmd.run(c, args[1:])
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		} else if errors.Is(err, errUsage) {
+			fmt.Fprintf(stderr, "usage: noxcrypt %s %s\n", cmd.name, cmd.args)
+			return 2
+		} else if err != nil {
+			fmt.Fprintf(stderr, "noxcrypt %s: %v\n", cmd.name, err)
+			return 1
+		}
+		return 0
+	}
+	fmt.Fprintf(stderr, "noxcrypt: unknown command %q\n", args[0])
+	c.usage()
+	return 2
+}
+
+func (c *cli) usage() {
+	fmt.Fprintln(c.stderr, "usage: noxcrypt <command> [flags] <args>")
+	fmt.Fprintln(c.stderr)
+	fmt.Fprintln(c.stderr, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(c.stderr, "  %-10s %s\n", cmd.name, cmd.short)
+	}
+}
+
+// flags creates a flag set for a command.
+func (c *cli) flags(cmd string) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	return fs
+}
+
+// keyFlag is a flag that accepts key names or indexes, see crypt.ParseKey.
+type keyFlag struct {
+	key crypt.Key
+	set bool
+}
+
+func (f *keyFlag) String() string {
+	if !f.set {
+		return ""
+	}
+	return f.key.String()
+}
+
+func (f *keyFlag) Set(s string) error {
+	k, err := crypt.ParseKey(s)
+	if err != nil {
+		return err
+	}
+	f.key, f.set = k, true
+	return nil
+}
+
+// resolve returns the key set by the flag, or determines it from file extensions of given paths.
+func (f *keyFlag) resolve(paths ...string) (crypt.Key, error) {
+	if f.set {
+		return f.key, nil
+	}
+	for _, path := range paths {
+		if k, ok := crypt.KeyForFile(path); ok {
+			return k, nil
+		}
+	}
+	return crypt.KeyNone, fmt.Errorf("cannot determine the key for %q, use -key", paths[0])
+}
+
+func init() {
+	register(&command{
+		name:  "encrypt",
+		args:  "[-key name] [-verify] <input> [output] | -glob pattern [-out dir] [-j n] [dir]...",
+		short: "encrypt files",
+		run: func(c *cli, args []string) error {
+			return c.convert("encrypt", args, true)
+		},
+	})
+	register(&command{
+		name:  "decrypt",
+		args:  "[-key name] [-verify] <input> [output] | -glob pattern [-out dir] [-j n] [dir]...",
+		short: "decrypt files",
+		run: func(c *cli, args []string) error {
+			return c.convert("decrypt", args, false)
+		},
+	})
+}
+
+func (c *cli) convert(name string, args []string, enc bool) error {
+	fs := c.flags(name)
+	var key keyFlag
+	fs.Var(&key, "key", "key name or index (default: detected by the file extension)")
+	verify := fs.Bool("verify", false, "verify the result before replacing the output file")
+	var b batch
+	b.flags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	conv := func(in, out string) error {
+		return convertFile(in, out, &key, enc, *verify)
+	}
+	if b.glob != "" {
+		return c.runBatch(&b, fs.Args(), conv)
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		return errUsage
+	}
+	in, out := fs.Arg(0), fs.Arg(0)
+	if fs.NArg() == 2 {
+		out = fs.Arg(1)
+	}
+	return conv(in, out)
+}
+
+func convertFile(in, out string, key *keyFlag, enc, verify bool) error {
+	if enc {
+		k, err := key.resolve(out, in)
+		if err != nil {
+			return err
+		}
+		return crypt.EncryptFile(out, in, k, verify)
+	}
+	k, err := key.resolve(in, out)
+	if err != nil {
+		return err
+	}
+	return crypt.DecryptFile(out, in, k, verify)
+}
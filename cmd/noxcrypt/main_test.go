@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	crypt "github.com/opennox/noxcrypt"
+	"github.com/opennox/noxcrypt/mapfile"
+)
+
+func runCmd(t testing.TB, args ...string) (int, string) {
+	var out bytes.Buffer
+	code := run(args, &out, &out)
+	return code, out.String()
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "test.txt")
+	enc := filepath.Join(dir, "test.map")
+	require.NoError(t, os.WriteFile(plain, []byte("map contents"), 0644))
+
+	code, out := runCmd(t, "encrypt", "-verify", plain, enc)
+	require.Equal(t, 0, code, out)
+	data, err := crypt.ReadFile(enc, crypt.KeyMap)
+	require.NoError(t, err)
+	require.Equal(t, "map contents\x00\x00\x00\x00", string(data))
+
+	code, out = runCmd(t, "decrypt", enc, plain)
+	require.Equal(t, 0, code, out)
+	data, err = os.ReadFile(plain)
+	require.NoError(t, err)
+	require.Equal(t, "map contents\x00\x00\x00\x00", string(data))
+
+	code, out = runCmd(t, "encrypt", "-key", "thing", plain)
+	require.Equal(t, 0, code, out)
+	data, err = crypt.ReadFile(plain, crypt.KeyThing)
+	require.NoError(t, err)
+	require.Equal(t, "map contents\x00\x00\x00\x00", string(data))
+
+	code, out = runCmd(t, "decrypt", plain)
+	require.Equal(t, 1, code)
+	require.Contains(t, out, "cannot determine the key")
+
+	code, out = runCmd(t, "decrypt")
+	require.Equal(t, 2, code)
+	require.True(t, strings.HasPrefix(out, "usage: noxcrypt decrypt"), out)
+
+	code, _ = runCmd(t, "unknown")
+	require.Equal(t, 2, code)
+}
+
+func TestCRC(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.map")
+	require.NoError(t, crypt.WriteFile(path, crypt.KeyMap, []byte("crc data")))
+
+	h := crypt.NewCRC()
+	h.Write([]byte("crc data"))
+	code, out := runCmd(t, "crc", "-std", path)
+	require.Equal(t, 0, code, out)
+	require.Equal(t, fmt.Sprintf("%08x %08x  %s\n", h.Sum32(), crc32.ChecksumIEEE([]byte("crc data")), path), out)
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	h.Reset()
+	h.Write(raw)
+	code, out = runCmd(t, "crc", "-raw", path)
+	require.Equal(t, 0, code, out)
+	require.Equal(t, fmt.Sprintf("%08x  %s\n", h.Sum32(), path), out)
+}
+
+func TestDetect(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.sav")
+	data := []byte("\xce\xfa\xde\xfa\x00\x00\x00\x00some map data...")
+	require.NoError(t, crypt.WriteFile(path, crypt.KeyMap, data))
+
+	code, out := runCmd(t, "detect", path)
+	require.Equal(t, 0, code, out)
+	require.Equal(t, path+": map, map (.map) (extension suggests save)\n", out)
+}
+
+func TestRecrypt(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "test.bin")
+	out := filepath.Join(dir, "test.map")
+	data := []byte("\xce\xfa\xde\xfa\x00\x00\x00\x00\x01MapInfo\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00")
+	require.NoError(t, crypt.WriteFile(in, crypt.KeyThing, data))
+
+	code, out2 := runCmd(t, "recrypt", "-from", "thing", "-fix-crc", in, out)
+	require.Equal(t, 0, code, out2)
+	require.NoError(t, mapfile.VerifyMapCRC(out))
+	got, err := crypt.ReadFile(out, crypt.KeyMap)
+	require.NoError(t, err)
+	require.Equal(t, data[8:], got[8:])
+
+	code, _ = runCmd(t, "recrypt", in, out)
+	require.Equal(t, 1, code)
+
+	// in-place conversion keeps permissions
+	require.NoError(t, os.Chmod(out, 0640))
+	code, out2 = runCmd(t, "recrypt", "-to", "thing", out, out)
+	require.Equal(t, 0, code, out2)
+	st, err := os.Stat(out)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0640), st.Mode().Perm())
+	got, err = crypt.ReadFile(out, crypt.KeyThing)
+	require.NoError(t, err)
+	require.Equal(t, data[8:], got[8:])
+}
+
+func TestBatch(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in")
+	out := filepath.Join(dir, "out")
+	for _, name := range []string{"a.map", "sub/b.map", "c.txt"} {
+		path := filepath.Join(in, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, crypt.WriteFile(path, crypt.KeyMap, []byte(name)))
+	}
+
+	code, res := runCmd(t, "decrypt", "-glob", "*.map", "-out", out, "-verify", "-j", "2", in)
+	require.Equal(t, 0, code, res)
+	require.Equal(t, "processed 2 files, 0 failed\n", res)
+	for _, name := range []string{"a.map", "sub/b.map"} {
+		data, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(name)))
+		require.NoError(t, err)
+		require.Equal(t, name, strings.TrimRight(string(data), "\x00"))
+	}
+	_, err := os.Stat(filepath.Join(out, "c.txt"))
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, os.WriteFile(filepath.Join(in, "bad.map"), []byte("bad"), 0644))
+	code, res = runCmd(t, "decrypt", "-glob", "*.map", "-out", out, in)
+	require.Equal(t, 1, code)
+	require.Contains(t, res, "processed 3 files, 1 failed")
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	crypt "github.com/opennox/noxcrypt"
+	"github.com/opennox/noxcrypt/mapfile"
+)
+
+func init() {
+	register(&command{
+		name:  "recrypt",
+		args:  "[-from name] [-to name] [-fix-crc] <input> <output>",
+		short: "convert a file from one key to another",
+		run:   (*cli).recrypt,
+	})
+}
+
+func (c *cli) recrypt(args []string) error {
+	fs := c.flags("recrypt")
+	var from, to keyFlag
+	fs.Var(&from, "from", "key of the input file (default: detected by the file extension)")
+	fs.Var(&to, "to", "key of the output file (default: detected by the file extension)")
+	fixCRC := fs.Bool("fix-crc", false, "recalculate the checksum stored in map and save files")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errUsage
+	}
+	in, out := fs.Arg(0), fs.Arg(1)
+	kfrom, err := from.resolve(in)
+	if err != nil {
+		return err
+	}
+	kto, err := to.resolve(out)
+	if err != nil {
+		return err
+	}
+	if err = recryptFile(out, in, kfrom, kto); err != nil {
+		return err
+	}
+	if !*fixCRC {
+		return nil
+	}
+	switch kto {
+	case crypt.KeyMap:
+		return mapfile.FixMapCRC(out)
+	case crypt.KeySave:
+		f, err := os.OpenFile(out, os.O_RDWR, 0)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err = crypt.FixSave(f); err != nil {
+			return err
+		}
+		return f.Close()
+	default:
+		return fmt.Errorf("no checksum is defined for %s files", kto)
+	}
+}
+
+// recryptFile converts the file with crypt.Recrypt. The output is written to a temporary file first,
+// so the input and output paths may be the same.
+func recryptFile(out, in string, from, to crypt.Key) error {
+	src, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	st, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	// keep permissions of the output file, if it exists, or use the ones of the input
+	mode := st.Mode().Perm()
+	if st, err := os.Stat(out); err == nil {
+		mode = st.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(out), "."+filepath.Base(out)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	if _, err = crypt.Recrypt(tmp, src, from, to); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	_ = src.Close()
+	return os.Rename(tmp.Name(), out)
+}
@@ -0,0 +1,56 @@
+package crypt
+
+import (
+	"io"
+
+	"golang.org/x/crypto/blowfish"
+)
+
+// NewCodec creates a codec for a given key. Options are applied to all readers and writers created by it.
+func NewCodec(key Key, opts ...Option) (*Codec, error) {
+	c, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Codec{key: key, c: c, opts: opts}, nil
+}
+
+// Codec describes how a specific file type is encoded, and creates readers and writers for it.
+// It is safe for concurrent use.
+type Codec struct {
+	key  Key
+	c    *blowfish.Cipher
+	opts []Option
+}
+
+// Key returns crypto key index used by the codec.
+func (c *Codec) Key() Key {
+	return c.key
+}
+
+// Reader creates a new decoder for a given stream.
+func (c *Codec) Reader(r io.Reader) *Reader {
+	return newReader(r, c.c, c.opts)
+}
+
+// Writer creates a new encoder for a given stream.
+func (c *Codec) Writer(w io.Writer) *Writer {
+	return newWriter(w, c.c, c.opts)
+}
+
+// File creates a new file that supports encode/decode and seek operations. See NewFile.
+func (c *Codec) File(f io.ReadWriteSeeker) *File {
+	cf := &File{c: c.c}
+	cf.Reset(f)
+	return cf
+}
+
+// Encrypt a buffer in place. Buffer size must be a multiple of Block.
+func (c *Codec) Encrypt(p []byte) error {
+	return EncodeWith(c.c, p)
+}
+
+// Decrypt a buffer in place. Buffer size must be a multiple of Block.
+func (c *Codec) Decrypt(p []byte) error {
+	return DecodeWith(c.c, p)
+}
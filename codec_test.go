@@ -0,0 +1,41 @@
+package crypt
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodec(t *testing.T) {
+	const (
+		key     = ThingBin
+		encoded = "\x2c\xc3\x70\x31\x5e\xda\x12\x3c\x49\x28\x27\x6d\x9a\x62\x8e\x94\x95\x36\x9f\xa2\x0d\xd0\x04\xee"
+		decoded = "ROLF\x01\x00\x00\x00\x03\x4d\x75\x64\x3e\x20\x03\x00\x08\x00\x00\x00\x00\x00\x00\x00"
+	)
+	_, err := NewCodec(maxKeyInd + 1)
+	require.ErrorIs(t, err, ErrInvalidKey)
+
+	c, err := NewCodec(key, WithStrictClose(true))
+	require.NoError(t, err)
+	require.Equal(t, key, c.Key())
+
+	buf := []byte(decoded)
+	require.NoError(t, c.Encrypt(buf))
+	require.Equal(t, encoded, string(buf))
+	require.NoError(t, c.Decrypt(buf))
+	require.Equal(t, decoded, string(buf))
+
+	out := bytes.NewBuffer(nil)
+	w := c.Writer(out)
+	require.True(t, w.StrictClose)
+	_, err = w.Write([]byte(decoded))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.Equal(t, encoded, out.String())
+
+	data, err := io.ReadAll(c.Reader(out))
+	require.NoError(t, err)
+	require.Equal(t, decoded, string(data))
+}
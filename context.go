@@ -0,0 +1,48 @@
+package crypt
+
+import (
+	"context"
+	"io"
+)
+
+// CopyContext copies the decoded data from src to dst, re-encoding it with the key of dst.
+// The context is checked between chunks, and ctx.Err() is returned if it is cancelled.
+// It returns the number of bytes copied.
+func CopyContext(ctx context.Context, dst *Writer, src *Reader) (int64, error) {
+	return copyContext(ctx, dst, src)
+}
+
+// DecryptContext copies the decoded data from src to dst. See CopyContext.
+func DecryptContext(ctx context.Context, dst io.Writer, src *Reader) (int64, error) {
+	return copyContext(ctx, dst, src)
+}
+
+// EncryptContext copies the data from src to dst, which encodes it. See CopyContext.
+func EncryptContext(ctx context.Context, dst *Writer, src io.Reader) (int64, error) {
+	return copyContext(ctx, dst, src)
+}
+
+func copyContext(ctx context.Context, dst io.Writer, src io.Reader) (int64, error) {
+	buf := make([]byte, copyChunk)
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		n, err := src.Read(buf)
+		if n > 0 {
+			m, werr := dst.Write(buf[:n])
+			total += int64(m)
+			if werr != nil {
+				return total, werr
+			} else if m != n {
+				return total, io.ErrShortWrite
+			}
+		}
+		if err == io.EOF {
+			return total, nil
+		} else if err != nil {
+			return total, err
+		}
+	}
+}
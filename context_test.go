@@ -0,0 +1,56 @@
+package crypt
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// cancelReader cancels the context after a given number of reads.
+type cancelReader struct {
+	r      io.Reader
+	n      int
+	cancel func()
+}
+
+func (r *cancelReader) Read(p []byte) (int, error) {
+	if r.n--; r.n == 0 {
+		r.cancel()
+	}
+	return r.r.Read(p)
+}
+
+func TestCopyContext(t *testing.T) {
+	data := bytes.Repeat([]byte("context!"), 4*copyChunk/Block)
+	enc, err := EncodePadded(MapKey, append([]byte{}, data...))
+	require.NoError(t, err)
+
+	src, err := NewReader(bytes.NewReader(enc), MapKey)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	dst, err := NewWriter(&buf, SaveKey)
+	require.NoError(t, err)
+	n, err := CopyContext(context.Background(), dst, src)
+	require.NoError(t, err)
+	require.NoError(t, dst.Close())
+	require.EqualValues(t, len(data), n)
+	exp, err := EncodePadded(SaveKey, append([]byte{}, data...))
+	require.NoError(t, err)
+	require.Equal(t, exp, buf.Bytes())
+
+	src.Reset(bytes.NewReader(enc))
+	var out bytes.Buffer
+	_, err = DecryptContext(context.Background(), &out, src)
+	require.NoError(t, err)
+	require.Equal(t, data, out.Bytes())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dst.Reset(&out)
+	n, err = EncryptContext(ctx, dst, &cancelReader{r: bytes.NewReader(data), n: 2, cancel: cancel})
+	require.ErrorIs(t, err, context.Canceled)
+	require.EqualValues(t, 2*copyChunk, n)
+}
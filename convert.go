@@ -0,0 +1,151 @@
+package crypt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/blowfish"
+)
+
+// EncryptFile encrypts the src file with a given key and atomically replaces dst with the result.
+// The data is written to a temporary file in the same directory, which is then renamed over dst,
+// thus dst is never left partially written. Both paths can point to the same file.
+//
+// The permissions of dst are preserved if it exists, otherwise permissions of src are used.
+// If verify is set, the temporary file is decrypted and compared with src before replacing dst.
+func EncryptFile(dst, src string, key Key, verify bool) error {
+	return convertFile(dst, src, key, true, verify)
+}
+
+// DecryptFile decrypts the src file with a given key and atomically replaces dst with the result.
+// The src size must be a multiple of Block. See EncryptFile for details.
+func DecryptFile(dst, src string, key Key, verify bool) error {
+	return convertFile(dst, src, key, false, verify)
+}
+
+func convertFile(dst, src string, key Key, enc, verify bool) (gerr error) {
+	c, err := NewCipher(key)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	st, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	mode := st.Mode().Perm()
+	if dst, err := os.Stat(dst); err == nil {
+		mode = dst.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if gerr != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+	if err = transform(tmp, in, c, enc); err != nil {
+		return err
+	}
+	if verify {
+		if _, err = in.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		cmp := &compareWriter{r: in, pad: enc}
+		if err = transform(cmp, tmp, c, !enc); err != nil {
+			return err
+		}
+		if err = cmp.Close(); err != nil {
+			return err
+		}
+	}
+	if err = tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	_ = in.Close()
+	return os.Rename(tmp.Name(), dst)
+}
+
+// transform encrypts or decrypts all data from src and writes it to dst.
+func transform(dst io.Writer, src io.Reader, c *blowfish.Cipher, enc bool) error {
+	if enc {
+		w := newWriter(dst, c, nil)
+		if _, err := w.ReadFrom(src); err != nil {
+			return err
+		}
+		return w.Close()
+	}
+	r := newReader(src, c, nil)
+	if _, err := r.WriteTo(dst); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("%w: file size is not a multiple of block size", ErrUnaligned)
+		}
+		return err
+	}
+	return nil
+}
+
+// compareWriter compares all written data with the data from r.
+// If pad is set, the written data may have additional zero padding at the end, up to the block size.
+type compareWriter struct {
+	r     io.Reader
+	pad   bool
+	off   int64
+	extra int
+	buf   []byte
+}
+
+func (w *compareWriter) Write(p []byte) (int, error) {
+	if cap(w.buf) < len(p) {
+		w.buf = make([]byte, len(p))
+	}
+	exp := w.buf[:len(p)]
+	n := 0
+	if w.extra == 0 {
+		var err error
+		n, err = io.ReadFull(w.r, exp)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return 0, err
+		}
+	}
+	if !bytes.Equal(p[:n], exp[:n]) {
+		return 0, fmt.Errorf("%w: data mismatch near offset %d", ErrVerify, w.off)
+	}
+	for i, b := range p[n:] {
+		w.extra++
+		if !w.pad || b != 0 || w.extra >= Block {
+			return 0, fmt.Errorf("%w: unexpected data at offset %d", ErrVerify, w.off+int64(n+i))
+		}
+	}
+	w.off += int64(len(p))
+	return len(p), nil
+}
+
+// Close checks that all data from r was compared.
+func (w *compareWriter) Close() error {
+	var b [1]byte
+	if n, _ := io.ReadFull(w.r, b[:]); n != 0 {
+		return fmt.Errorf("%w: missing data at offset %d", ErrVerify, w.off)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package crypt
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecryptFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "plain.bin")
+	dst := filepath.Join(dir, "enc.bin")
+	data := bytes.Repeat([]byte("convert me"), 1000)
+	require.NoError(t, os.WriteFile(src, data, 0600))
+
+	require.NoError(t, EncryptFile(dst, src, ThingBin, true))
+	st, err := os.Stat(dst)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), st.Mode().Perm())
+	out, err := ReadFile(dst, ThingBin)
+	require.NoError(t, err)
+	require.Equal(t, data, out[:len(data)])
+
+	// in place
+	require.NoError(t, DecryptFile(dst, dst, ThingBin, true))
+	out, err = os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, data, out[:len(data)])
+	require.Len(t, out, int(EncodedSize(int64(len(data)))))
+
+	ents, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, ents, 2)
+
+	require.NoError(t, os.WriteFile(src, data[:5], 0600))
+	err = DecryptFile(dst, src, ThingBin, false)
+	require.ErrorIs(t, err, ErrUnaligned)
+	ents, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, ents, 2)
+}
+
+func TestCompareWriter(t *testing.T) {
+	w := &compareWriter{r: bytes.NewReader([]byte("abcdef")), pad: true}
+	_, err := w.Write([]byte("abc"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("def\x00\x00"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	w = &compareWriter{r: bytes.NewReader([]byte("abcdef"))}
+	_, err = w.Write([]byte("abd"))
+	require.ErrorIs(t, err, ErrVerify)
+
+	w = &compareWriter{r: bytes.NewReader([]byte("abcdef"))}
+	_, err = w.Write([]byte("abcdef\x00"))
+	require.ErrorIs(t, err, ErrVerify)
+
+	w = &compareWriter{r: bytes.NewReader([]byte("abcdef"))}
+	_, err = w.Write([]byte("abc"))
+	require.NoError(t, err)
+	require.ErrorIs(t, w.Close(), ErrVerify)
+}
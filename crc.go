@@ -1,8 +1,9 @@
 package crypt
 
-import "hash/crc32"
-
-var crcTable = simpleMakeTable(crc32.IEEE)
+import (
+	"hash"
+	"hash/crc32"
+)
 
 // ZeroCRC is an initial value for UpdateCRC function.
 const ZeroCRC = uint32(0xFFFFFFFF)
@@ -10,18 +11,182 @@ const ZeroCRC = uint32(0xFFFFFFFF)
 // ZeroCRCStd is an initial value for UpdateCRCStd function.
 const ZeroCRCStd = uint32(0)
 
+// CRCMode selects the data covered by the running CRC of Reader and Writer.
+type CRCMode int
+
+const (
+	// CRCPlain computes the checksum of decoded data. This is the default.
+	CRCPlain = CRCMode(iota)
+	// CRCCipher computes the checksum of encoded data, as stored in the file.
+	CRCCipher
+	// CRCBoth computes both checksums at once, see Writer.CipherCRC. Reader treats it as CRCPlain.
+	CRCBoth
+)
+
 // UpdateCRC is a CRC update function used in Nox.
 func UpdateCRC(crc uint32, p []byte) uint32 {
-	// Function is very similar to crc32.simpleUpdate, but omits the first bit invert.
+	// Function is the same as the standard IEEE CRC, but omits the first bit invert.
 	// However, implementation starts from 0xFFFFFFFF, so _one_ call to this is exactly the same.
-	// crc = ^crc
-	for _, v := range p {
-		crc = crcTable[byte(crc)^v] ^ (crc >> 8)
+	// Inverting the input compensates for the missing invert and allows using the optimized implementation.
+	return UpdateCRCTable(crc, crc32.IEEETable, p)
+}
+
+// NewCRCTable creates a table for a given polynomial (in reversed form, see hash/crc32),
+// for use with UpdateCRCTable, UpdateCRCStdTable and NewCRCWithTable.
+// Tables for crc32.IEEE and crc32.Castagnoli use hardware acceleration, if available.
+func NewCRCTable(poly uint32) *crc32.Table {
+	return crc32.MakeTable(poly)
+}
+
+// UpdateCRCTable is the same as UpdateCRC, but uses a custom table, see NewCRCTable.
+func UpdateCRCTable(crc uint32, tab *crc32.Table, p []byte) uint32 {
+	return crc32.Update(^crc, tab, p)
+}
+
+// UpdateCRCStdTable is the same as UpdateCRCStd, but uses a custom table, see NewCRCTable.
+func UpdateCRCStdTable(crc uint32, tab *crc32.Table, p []byte) uint32 {
+	return crc32.Update(crc, tab, p)
+}
+
+// updateCRCBlocks applies UpdateCRC to each block of p separately, the same way as Writer does.
+func updateCRCBlocks(crc uint32, p []byte) uint32 {
+	for i := 0; i+Block <= len(p); i += Block {
+		crc = UpdateCRC(crc, p[i:i+Block])
 	}
-	return ^crc
+	return crc
 }
 
 // UpdateCRCStd is a standard CRC update function.
 func UpdateCRCStd(crc uint32, p []byte) uint32 {
-	return simpleUpdate(crc, crcTable, p)
+	return crc32.Update(crc, crc32.IEEETable, p)
+}
+
+// NewCRC creates a new hash.Hash32 computing the Nox CRC checksum.
+//
+// The data is processed in blocks of Block size, the same way as Writer does.
+// Thus, the checksum is the same as reported by Writer.CRC for the same data.
+// If the size of the data is not a multiple of Block, the last block is padded with zeros.
+func NewCRC() hash.Hash32 {
+	return NewCRCWithTable(crc32.IEEETable)
+}
+
+// NewCRCWithTable is the same as NewCRC, but uses a custom table, see NewCRCTable.
+func NewCRCWithTable(tab *crc32.Table) hash.Hash32 {
+	h := &noxCRC{tab: tab}
+	h.Reset()
+	return h
+}
+
+type noxCRC struct {
+	tab *crc32.Table
+	crc uint32
+	buf [Block]byte
+	n   int
+}
+
+func (h *noxCRC) Size() int { return 4 }
+
+func (h *noxCRC) BlockSize() int { return Block }
+
+func (h *noxCRC) Reset() {
+	if h.tab == nil {
+		h.tab = crc32.IEEETable
+	}
+	h.crc = ZeroCRC
+	h.n = 0
+}
+
+func (h *noxCRC) Write(p []byte) (int, error) {
+	total := len(p)
+	if h.n != 0 {
+		n := copy(h.buf[h.n:], p)
+		h.n += n
+		p = p[n:]
+		if h.n < Block {
+			return total, nil
+		}
+		h.crc = UpdateCRCTable(h.crc, h.tab, h.buf[:])
+		h.n = 0
+	}
+	for len(p) >= Block {
+		h.crc = UpdateCRCTable(h.crc, h.tab, p[:Block])
+		p = p[Block:]
+	}
+	h.n = copy(h.buf[:], p)
+	return total, nil
+}
+
+func (h *noxCRC) Sum32() uint32 {
+	if h.n == 0 {
+		return h.crc
+	}
+	var b [Block]byte
+	copy(b[:], h.buf[:h.n])
+	return UpdateCRCTable(h.crc, h.tab, b[:])
+}
+
+func (h *noxCRC) Sum(in []byte) []byte {
+	s := h.Sum32()
+	return append(in, byte(s>>24), byte(s>>16), byte(s>>8), byte(s))
+}
+
+// CombineCRC returns the checksum of two concatenated parts, given the checksum of each part (computed
+// from ZeroCRC) and the length of the second part. It is the equivalent of zlib's crc32_combine for the Nox CRC.
+//
+// The length of the first part must be a multiple of Block, otherwise its checksum includes zero padding
+// that is not present in the combined data. The second part may be unaligned: its checksum covers the data
+// padded with zeros to a multiple of Block, same as NewCRC does, thus the padded length is used.
+func CombineCRC(crc1, crc2 uint32, len2 int64) uint32 {
+	if len2 <= 0 {
+		return crc1
+	}
+	len2 = EncodedSize(len2)
+	// Each block update is an affine function of the previous CRC, thus the difference caused by the first part
+	// is the same as running it through len2 zero bytes. See zlib for the details of the matrix method.
+	var even, odd [32]uint32
+	odd[0] = crc32.IEEE
+	row := uint32(1)
+	for i := 1; i < 32; i++ {
+		odd[i] = row
+		row <<= 1
+	}
+	gf2MatrixSquare(&even, &odd) // 2 zero bits
+	gf2MatrixSquare(&odd, &even) // 4 zero bits
+	v := crc1 ^ ZeroCRC
+	for {
+		gf2MatrixSquare(&even, &odd)
+		if len2&1 != 0 {
+			v = gf2MatrixTimes(&even, v)
+		}
+		len2 >>= 1
+		if len2 == 0 {
+			break
+		}
+		gf2MatrixSquare(&odd, &even)
+		if len2&1 != 0 {
+			v = gf2MatrixTimes(&odd, v)
+		}
+		len2 >>= 1
+		if len2 == 0 {
+			break
+		}
+	}
+	return v ^ crc2
+}
+
+func gf2MatrixTimes(mat *[32]uint32, vec uint32) uint32 {
+	var sum uint32
+	for i := 0; vec != 0; i++ {
+		if vec&1 != 0 {
+			sum ^= mat[i]
+		}
+		vec >>= 1
+	}
+	return sum
+}
+
+func gf2MatrixSquare(dst, mat *[32]uint32) {
+	for i := range dst {
+		dst[i] = gf2MatrixTimes(mat, mat[i])
+	}
 }
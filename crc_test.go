@@ -0,0 +1,160 @@
+package crypt
+
+import (
+	"bytes"
+	"hash/crc32"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCRC(t *testing.T) {
+	data := []byte("ROLF\x01\x00\x00\x00\x03\x4d\x75\x64\x3e\x20\x03\x00\x08\x00\x00\x00\x00\x00")
+	w, err := NewWriter(bytes.NewBuffer(nil), NoKey)
+	require.NoError(t, err)
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	h := NewCRC()
+	require.Equal(t, ZeroCRC, h.Sum32())
+	for _, b := range data {
+		_, err = h.Write([]byte{b})
+		require.NoError(t, err)
+	}
+	require.Equal(t, w.CRC(), h.Sum32())
+	require.Equal(t, w.CRC(), h.Sum32())
+
+	h.Reset()
+	_, err = h.Write(data[:3])
+	require.NoError(t, err)
+	_, err = h.Write(data[3:])
+	require.NoError(t, err)
+	require.Equal(t, w.CRC(), h.Sum32())
+	s := w.CRC()
+	require.Equal(t, []byte{1, byte(s >> 24), byte(s >> 16), byte(s >> 8), byte(s)}, h.Sum([]byte{1}))
+}
+
+func TestCRCMode(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 20)
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf, ThingBin, WithCRCMode(CRCCipher))
+	require.NoError(t, err)
+	_, err = w.Write(data[:3])
+	require.NoError(t, err)
+	_, err = w.Write(data[3:])
+	require.NoError(t, err)
+	require.NoError(t, w.WriteZeros(3*Block))
+	require.NoError(t, w.Close())
+	h := NewCRC()
+	_, _ = h.Write(buf.Bytes())
+	require.Equal(t, h.Sum32(), w.CRC())
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), ThingBin, WithCRCMode(CRCCipher))
+	require.NoError(t, err)
+	_, err = io.Copy(io.Discard, r)
+	require.NoError(t, err)
+	require.Equal(t, w.CRC(), r.CRC())
+
+	for _, mode := range []CRCMode{CRCPlain, CRCCipher} {
+		enc := bytes.NewBuffer(nil)
+		w, err = NewWriter(enc, ThingBin, WithCRCMode(mode), WithCRCTrailer(true))
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		r, err = NewReader(bytes.NewReader(enc.Bytes()), ThingBin, WithCRCMode(mode), WithCRCTrailer(true), WithReadAhead(-1))
+		require.NoError(t, err)
+		_, err = io.Copy(io.Discard, r)
+		require.NoError(t, err)
+		require.Equal(t, w.CRC(), r.CRC())
+	}
+}
+
+func TestCipherCRC(t *testing.T) {
+	data := bytes.Repeat([]byte("abcdefghij"), 20)
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf, ThingBin, WithCRCMode(CRCBoth))
+	require.NoError(t, err)
+	_, err = w.Write(data[:5])
+	require.NoError(t, err)
+	_, err = w.Write(data[5:])
+	require.NoError(t, err)
+	require.NoError(t, w.WriteZeros(2*Block))
+	require.NoError(t, w.Close())
+
+	plain := bytes.Clone(buf.Bytes())
+	require.NoError(t, Decode(plain, ThingBin))
+	h := NewCRC()
+	_, _ = h.Write(plain)
+	require.Equal(t, h.Sum32(), w.CRC())
+	h.Reset()
+	_, _ = h.Write(buf.Bytes())
+	require.Equal(t, h.Sum32(), w.CipherCRC())
+}
+
+func TestCombineCRC(t *testing.T) {
+	data := make([]byte, 100*Block)
+	for i := range data {
+		data[i] = byte(i*31 + i/7)
+	}
+	sum := func(p []byte) uint32 {
+		h := NewCRC()
+		_, _ = h.Write(p)
+		return h.Sum32()
+	}
+	exp := sum(data)
+	for _, split := range []int{0, 1, 7, 50, 99, 100} {
+		a, b := data[:split*Block], data[split*Block:]
+		require.Equal(t, exp, CombineCRC(sum(a), sum(b), int64(len(b))), "split %d", split)
+	}
+	// the second part is padded to the block size
+	data = data[:21]
+	require.Equal(t, sum(data), CombineCRC(sum(data[:16]), sum(data[16:]), 5))
+}
+
+func TestUpdateCRC(t *testing.T) {
+	// reference implementation of the Nox CRC
+	ref := func(crc uint32, p []byte) uint32 {
+		for _, v := range p {
+			crc ^= uint32(v)
+			for i := 0; i < 8; i++ {
+				if crc&1 != 0 {
+					crc = (crc >> 1) ^ 0xedb88320
+				} else {
+					crc >>= 1
+				}
+			}
+		}
+		return ^crc
+	}
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i*13 + 5)
+	}
+	for _, n := range []int{0, 1, Block, 100, len(data)} {
+		require.Equal(t, ref(ZeroCRC, data[:n]), UpdateCRC(ZeroCRC, data[:n]))
+		require.Equal(t, ref(0x12345678, data[:n]), UpdateCRC(0x12345678, data[:n]))
+		require.Equal(t, ref(^uint32(0x1234), data[:n]), UpdateCRCStd(0x1234, data[:n]))
+	}
+}
+
+func TestCRCTable(t *testing.T) {
+	tab := NewCRCTable(crc32.Castagnoli)
+	data := []byte("0123456789abcdef")
+	require.Equal(t, crc32.Checksum(data, tab), UpdateCRCStdTable(0, tab, data))
+	require.Equal(t, crc32.Update(^ZeroCRC, tab, data[:Block]), UpdateCRCTable(ZeroCRC, tab, data[:Block]))
+
+	h := NewCRCWithTable(tab)
+	_, _ = h.Write(data[:3])
+	_, _ = h.Write(data[3:])
+	exp := UpdateCRCTable(UpdateCRCTable(ZeroCRC, tab, data[:Block]), tab, data[Block:])
+	require.Equal(t, exp, h.Sum32())
+
+	h = NewCRCWithTable(NewCRCTable(crc32.IEEE))
+	_, _ = h.Write(data)
+	h2 := NewCRC()
+	_, _ = h2.Write(data)
+	require.Equal(t, h2.Sum32(), h.Sum32())
+}
@@ -2,57 +2,74 @@
 package crypt
 
 import (
-	"errors"
 	"fmt"
-	"path/filepath"
-	"strings"
+	"io"
 
 	"golang.org/x/crypto/blowfish"
 )
 
-// Constants for known crypto keys.
-const (
-	NoKey       = -1
-	SoundSetBin = 5
-	ThingBin    = 7
-	GameDataBin = 8
-	ModifierBin = 13
-	MonsterBin  = 23
-	MapKey      = 19
-	SaveKey     = 27
-)
-
 const Block = blowfish.BlockSize
 
-var errInvalidSize = errors.New("invalid buffer size")
-
-// KeyForFile return crypto key for a given file. If the file is unknown, it returns false.
-func KeyForFile(path string) (int, bool) {
-	path = filepath.Base(path)
-	path = strings.ToLower(path)
-	switch path {
-	case "soundset.bin":
-		return SoundSetBin, true
-	case "thing.bin":
-		return ThingBin, true
-	case "gamedata.bin":
-		return GameDataBin, true
-	case "modifier.bin":
-		return ModifierBin, true
-	case "monster.bin":
-		return MonsterBin, true
-	}
-	switch filepath.Ext(path) {
-	case ".map":
-		return MapKey, true
-	case ".plr":
-		return SaveKey, true
-	}
-	return 0, false
+// DefaultMaxAlloc is the default limit for a single allocation made by Reader helpers.
+const DefaultMaxAlloc = 64 << 20
+
+// DefaultReadAhead is the default number of bytes Reader reads from the underlying reader at once.
+const DefaultReadAhead = 4 << 10
+
+// copyChunk is the size of the buffer used by Reader.WriteTo and similar bulk operations.
+const copyChunk = 32 << 10
+
+// DefaultMaxOffset is the default limit for offsets accepted by Reader and Writer.
+const DefaultMaxOffset = 1 << 40
+
+func isZero(p []byte) bool {
+	for _, b := range p {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// seekTarget validates seek arguments and converts them to an absolute offset.
+// Function cur must return current logical position in the stream.
+// The position of the seeker is not changed, unless whence is io.SeekEnd.
+func seekTarget(s io.Seeker, off int64, whence int, cur func() (int64, error)) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		pos, err := cur()
+		if err != nil {
+			return 0, err
+		}
+		off += pos
+	case io.SeekEnd:
+		pos, err := seek(s, 0, io.SeekCurrent)
+		if err != nil {
+			return 0, err
+		}
+		end, err := seek(s, 0, io.SeekEnd)
+		if err != nil {
+			return 0, err
+		}
+		if end+off < 0 {
+			// restore the position, since we won't seek anywhere
+			if _, err = seek(s, pos, io.SeekStart); err != nil {
+				return 0, err
+			}
+		}
+		off += end
+	default:
+		return 0, ErrInvalidWhence
+	}
+	if off < 0 {
+		return 0, ErrNegativeOffset
+	}
+	return off, nil
 }
 
 // Encode a buffer with a given key.
-func Encode(p []byte, key int) error {
+func Encode(p []byte, key Key) error {
 	if key == NoKey {
 		return nil
 	}
@@ -69,7 +86,7 @@ func EncodeWith(c *blowfish.Cipher, p []byte) error {
 		return nil
 	}
 	if len(p)%Block != 0 {
-		return errInvalidSize
+		return ErrInvalidSize
 	}
 	for i := 0; i < len(p); i += Block {
 		b := p[i : i+Block]
@@ -79,12 +96,12 @@ func EncodeWith(c *blowfish.Cipher, p []byte) error {
 }
 
 // Decode a buffer with a given key.
-func Decode(p []byte, key int) error {
+func Decode(p []byte, key Key) error {
 	if key == NoKey {
 		return nil
 	}
 	if len(p)%Block != 0 {
-		return errInvalidSize
+		return ErrInvalidSize
 	}
 	c, err := NewCipher(key)
 	if err != nil {
@@ -97,13 +114,41 @@ func Decode(p []byte, key int) error {
 	return nil
 }
 
+// EncodePadded pads the data with zeros to a multiple of Block and encodes it with a given key.
+// The data is encoded in place, if its capacity allows it. Otherwise, a new buffer is allocated.
+// The original data must not be used after this call, only the returned slice.
+func EncodePadded(key Key, data []byte) ([]byte, error) {
+	n := len(data)
+	if pad := Padding(int64(n)); pad != 0 {
+		if cap(data)-n >= pad {
+			data = data[:n+pad]
+			clear(data[n:])
+		} else {
+			data = append(data, make([]byte, pad)...)
+		}
+	}
+	if err := Encode(data, key); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// DecodePadded decodes the data in place with a given key and returns it.
+// Size of the data must be a multiple of Block. Zero padding is preserved, since the original size is unknown.
+func DecodePadded(key Key, data []byte) ([]byte, error) {
+	if err := Decode(data, key); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
 // DecodeWith decodes a buffer with a given cipher.
 func DecodeWith(c *blowfish.Cipher, p []byte) error {
 	if c == nil {
 		return nil
 	}
 	if len(p)%Block != 0 {
-		return errInvalidSize
+		return ErrInvalidSize
 	}
 	for i := 0; i < len(p); i += Block {
 		b := p[i : i+Block]
@@ -112,17 +157,62 @@ func DecodeWith(c *blowfish.Cipher, p []byte) error {
 	return nil
 }
 
+// EncryptBlocks encrypts src into dst with a given key. Size of src must be a multiple of Block,
+// and dst must be at least as large as src. Buffers may overlap only if they start at the same address.
+func EncryptBlocks(key Key, dst, src []byte) error {
+	return cryptBlocks(key, dst, src, true)
+}
+
+// DecryptBlocks decrypts src into dst with a given key. See EncryptBlocks for buffer requirements.
+func DecryptBlocks(key Key, dst, src []byte) error {
+	return cryptBlocks(key, dst, src, false)
+}
+
+func cryptBlocks(key Key, dst, src []byte, enc bool) error {
+	if len(src)%Block != 0 {
+		return ErrInvalidSize
+	}
+	if len(dst) < len(src) {
+		return fmt.Errorf("%w: destination is too small", ErrInvalidSize)
+	}
+	c, err := NewCipher(key)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		copy(dst, src)
+		return nil
+	}
+	for i := 0; i < len(src); i += Block {
+		if enc {
+			c.Encrypt(dst[i:i+Block], src[i:i+Block])
+		} else {
+			c.Decrypt(dst[i:i+Block], src[i:i+Block])
+		}
+	}
+	return nil
+}
+
 // NewCipher creates a new cipher using Nox key with a given index.
-func NewCipher(key int) (*blowfish.Cipher, error) {
+func NewCipher(key Key) (*blowfish.Cipher, error) {
 	if key == NoKey {
 		return nil, nil
-	} else if key < 0 || key > maxKeyInd {
-		return nil, fmt.Errorf("invalid key index: %d", key)
+	} else if !key.Valid() {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidKey, key)
 	}
-	data := keyByInd(key)
+	data := keyByInd(int(key))
 	return blowfish.NewCipher(data)
 }
 
+// NewCipherBytes creates a new cipher using custom key material. The key must be from 1 to 56 bytes long.
+func NewCipherBytes(key []byte) (*blowfish.Cipher, error) {
+	c, err := blowfish.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: key size %d", ErrInvalidKey, len(key))
+	}
+	return c, nil
+}
+
 const (
 	tableSize     = 896
 	tableKeySize  = 56
@@ -1,6 +1,8 @@
 package crypt
 
 import (
+	"bytes"
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -38,3 +40,81 @@ func TestEncodeBypass(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, decoded, string(buf))
 }
+
+func TestKeyBytes(t *testing.T) {
+	const (
+		encoded = "\x2c\xc3\x70\x31\x5e\xda\x12\x3c"
+		decoded = "ROLF\x01\x00\x00\x00"
+	)
+	key := keyByInd(int(ThingBin))
+
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriterBytes(buf, key)
+	require.NoError(t, err)
+	_, err = w.Write([]byte(decoded))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.Equal(t, encoded, buf.String())
+
+	r, err := NewReaderBytes(buf, key)
+	require.NoError(t, err)
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, decoded, string(out))
+
+	_, err = NewCipherBytes(nil)
+	require.ErrorIs(t, err, ErrInvalidKey)
+	_, err = NewReaderBytes(buf, make([]byte, 57))
+	require.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestCryptBlocks(t *testing.T) {
+	const (
+		key     = ThingBin
+		encoded = "\x2c\xc3\x70\x31\x5e\xda\x12\x3c"
+		decoded = "ROLF\x01\x00\x00\x00"
+	)
+	src := []byte(decoded)
+	dst := make([]byte, 2*Block)
+	require.NoError(t, EncryptBlocks(key, dst, src))
+	require.Equal(t, encoded, string(dst[:Block]))
+	require.Equal(t, decoded, string(src))
+
+	require.NoError(t, DecryptBlocks(key, dst, dst[:Block]))
+	require.Equal(t, decoded, string(dst[:Block]))
+
+	require.NoError(t, DecryptBlocks(NoKey, dst, []byte(encoded)))
+	require.Equal(t, encoded, string(dst[:Block]))
+
+	require.ErrorIs(t, EncryptBlocks(key, dst, src[:4]), ErrInvalidSize)
+	require.ErrorIs(t, EncryptBlocks(key, dst[:4], src), ErrInvalidSize)
+	require.ErrorIs(t, EncryptBlocks(maxKeyInd+1, dst, src), ErrInvalidKey)
+}
+
+func TestEncodePadded(t *testing.T) {
+	const (
+		key     = ThingBin
+		encoded = "\x2c\xc3\x70\x31\x5e\xda\x12\x3c"
+	)
+	// padding must be cleared, even if the capacity contains garbage
+	buf := make([]byte, 4, Block)
+	copy(buf[:Block], "ROLFxxxx")
+	out, err := EncodePadded(key, []byte("ROLF\x01"))
+	require.NoError(t, err)
+	require.Len(t, out, Block)
+
+	out, err = EncodePadded(key, append([]byte("ROLF\x01"), 0, 0, 0))
+	require.NoError(t, err)
+	require.Equal(t, encoded, string(out))
+
+	out, err = EncodePadded(key, buf)
+	require.NoError(t, err)
+	require.Same(t, &buf[0], &out[0])
+
+	out, err = DecodePadded(key, out)
+	require.NoError(t, err)
+	require.Equal(t, "ROLF\x00\x00\x00\x00", string(out))
+
+	_, err = DecodePadded(key, out[:4])
+	require.ErrorIs(t, err, ErrInvalidSize)
+}
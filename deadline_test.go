@@ -0,0 +1,79 @@
+package crypt
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReaderDeadline(t *testing.T) {
+	const (
+		key     = ThingBin
+		encoded = "\x2c\xc3\x70\x31\x5e\xda\x12\x3c\x49\x28\x27\x6d\x9a\x62\x8e\x94\x95\x36\x9f\xa2\x0d\xd0\x04\xee"
+		decoded = "ROLF\x01\x00\x00\x00\x03\x4d\x75\x64\x3e\x20\x03\x00\x08\x00\x00\x00\x00\x00\x00\x00"
+	)
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	r, err := NewReader(c1, key)
+	require.NoError(t, err)
+
+	go func() {
+		_, _ = c2.Write([]byte(encoded[:3]))
+	}()
+
+	require.NoError(t, r.SetReadDeadline(time.Now().Add(10*time.Millisecond)))
+	var buf [4]byte
+	_, err = r.Read(buf[:])
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	go func() {
+		_, _ = c2.Write([]byte(encoded[3:]))
+		c2.Close()
+	}()
+	require.NoError(t, r.SetReadDeadline(time.Time{}))
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, decoded, string(out))
+
+	r.Reset(strings.NewReader(""))
+	err = r.SetReadDeadline(time.Time{})
+	require.ErrorIs(t, err, errors.ErrUnsupported)
+}
+
+func TestWriterDeadline(t *testing.T) {
+	const (
+		key     = ThingBin
+		encoded = "\x2c\xc3\x70\x31\x5e\xda\x12\x3c\x49\x28\x27\x6d\x9a\x62\x8e\x94\x95\x36\x9f\xa2\x0d\xd0\x04\xee"
+		decoded = "ROLF\x01\x00\x00\x00\x03\x4d\x75\x64\x3e\x20\x03\x00\x08\x00\x00\x00\x00\x00\x00\x00"
+	)
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	w, err := NewWriter(c1, key)
+	require.NoError(t, err)
+
+	require.NoError(t, w.SetWriteDeadline(time.Now().Add(10*time.Millisecond)))
+	_, err = w.Write([]byte(decoded[:Block]))
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	require.NoError(t, w.SetWriteDeadline(time.Time{}))
+	res := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(c2)
+		res <- string(data)
+	}()
+	_, err = w.Write([]byte(decoded[Block:]))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	c1.Close()
+	require.Equal(t, encoded, <-res)
+}
@@ -0,0 +1,72 @@
+package crypt
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	detectBlocks = 8          // number of blocks used for key detection
+	mapMagic     = 0xFADEFACE // magic value of Nox map files
+)
+
+// knownKeys lists keys that are tried by DetectKey, in order of preference.
+var knownKeys = []Key{
+	KeyMap, KeySave, KeyThing, KeyGameData, KeyModifier, KeyMonster, KeySoundSet, KeyNone,
+}
+
+// DetectKey tries to determine the key used to encrypt the data by decrypting first few blocks with all known keys.
+//
+// The decrypted data is scored using format heuristics: known magic values, printable text and zero bytes.
+// KeyNone is returned if the data looks like it is not encrypted at all.
+// If none of the keys produces a plausible result, ErrUnknownFile is returned.
+func DetectKey(r io.ReaderAt) (Key, error) {
+	buf := make([]byte, detectBlocks*Block)
+	n, err := r.ReadAt(buf, 0)
+	if err != nil && err != io.EOF {
+		return KeyNone, wrapIO("read", err)
+	}
+	n -= n % Block
+	if n == 0 {
+		return KeyNone, fmt.Errorf("%w: not enough data to detect the key", ErrUnknownFile)
+	}
+	buf = buf[:n]
+	tmp := make([]byte, n)
+	best, bestScore := KeyNone, -1
+	for _, k := range knownKeys {
+		copy(tmp, buf)
+		if err := Decode(tmp, k); err != nil {
+			return KeyNone, err
+		}
+		if s := detectScore(k, tmp); s > bestScore {
+			best, bestScore = k, s
+		}
+	}
+	// require most of the bytes to look plausible
+	if bestScore < n*3/4 {
+		return KeyNone, fmt.Errorf("%w: cannot detect the key", ErrUnknownFile)
+	}
+	return best, nil
+}
+
+// detectScore estimates how likely it is that p is a valid decrypted data for a given key.
+func detectScore(k Key, p []byte) int {
+	score := 0
+	if binary.LittleEndian.Uint32(p) == mapMagic {
+		if k == KeyMap {
+			score += len(p)
+		} else {
+			score += len(p) / 2
+		}
+	}
+	for _, b := range p {
+		switch {
+		case b == 0, b == '\t', b == '\n', b == '\r':
+			score++
+		case b >= 0x20 && b < 0x7f:
+			score++
+		}
+	}
+	return score
+}
@@ -0,0 +1,50 @@
+package crypt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectKey(t *testing.T) {
+	encode := func(t testing.TB, key Key, data []byte) *bytes.Reader {
+		buf := bytes.NewBuffer(nil)
+		w, err := NewWriter(buf, key)
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return bytes.NewReader(buf.Bytes())
+	}
+	m := make([]byte, 80)
+	binary.LittleEndian.PutUint32(m, mapMagic)
+	copy(m[8:], "Estate.map")
+	text := []byte("some printable text header, followed by more printable text\n")
+	for _, c := range []struct {
+		name string
+		key  Key
+		data []byte
+	}{
+		{"map", KeyMap, m},
+		{"thing", KeyThing, text},
+		{"save", KeySave, text},
+		{"plain", KeyNone, text},
+		{"short", KeyModifier, []byte("ROLF")},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			k, err := DetectKey(encode(t, c.key, c.data))
+			require.NoError(t, err)
+			require.Equal(t, c.key, k)
+		})
+	}
+
+	rnd := make([]byte, 64)
+	rand.New(rand.NewSource(1)).Read(rnd)
+	_, err := DetectKey(bytes.NewReader(rnd))
+	require.ErrorIs(t, err, ErrUnknownFile)
+	_, err = DetectKey(bytes.NewReader(rnd[:4]))
+	require.ErrorIs(t, err, ErrUnknownFile)
+}
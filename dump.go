@@ -0,0 +1,46 @@
+package crypt
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DumpReader mirrors all data decoded by Read (and helpers based on it) to w as an offset-annotated hexdump.
+// Offsets are relative to the beginning of the Reader, or are absolute if the Reader was seeked.
+// Each read starts a new dump line, which helps to see how the data is consumed.
+// Errors returned by w are ignored. Passing nil writer disables the dump.
+func DumpReader(r *Reader, w io.Writer) {
+	r.dump = w
+}
+
+// hexDump writes data in a format similar to hexdump -C.
+func hexDump(w io.Writer, off int64, p []byte) {
+	var sb strings.Builder
+	for len(p) > 0 {
+		n := min(len(p), 16)
+		line := p[:n]
+		p = p[n:]
+		fmt.Fprintf(&sb, "%08x ", off)
+		for i := 0; i < 16; i++ {
+			if i == 8 {
+				sb.WriteByte(' ')
+			}
+			if i < len(line) {
+				fmt.Fprintf(&sb, " %02x", line[i])
+			} else {
+				sb.WriteString("   ")
+			}
+		}
+		sb.WriteString("  |")
+		for _, b := range line {
+			if b < 0x20 || b > 0x7e {
+				b = '.'
+			}
+			sb.WriteByte(b)
+		}
+		sb.WriteString("|\n")
+		off += int64(n)
+	}
+	_, _ = io.WriteString(w, sb.String())
+}
@@ -0,0 +1,32 @@
+package crypt
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDumpReader(t *testing.T) {
+	const (
+		key     = ThingBin
+		encoded = "\x2c\xc3\x70\x31\x5e\xda\x12\x3c\x49\x28\x27\x6d\x9a\x62\x8e\x94\x95\x36\x9f\xa2\x0d\xd0\x04\xee"
+	)
+	r, err := NewReader(strings.NewReader(encoded), key)
+	require.NoError(t, err)
+	var sb strings.Builder
+	DumpReader(r, &sb)
+
+	v, err := r.ReadU32()
+	require.NoError(t, err)
+	require.Equal(t, uint32(0x464c4f52), v)
+	_, err = r.Seek(9, io.SeekStart)
+	require.NoError(t, err)
+	_, err = io.ReadAll(r)
+	require.NoError(t, err)
+
+	require.Equal(t, `00000000  52 4f 4c 46                                       |ROLF|
+00000009  4d 75 64 3e 20 03 00 08  00 00 00 00 00 00 00     |Mud> ..........|
+`, sb.String())
+}
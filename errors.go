@@ -0,0 +1,118 @@
+package crypt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrInvalidKey is returned when the key index is unknown.
+	ErrInvalidKey = errors.New("crypt: invalid key index")
+	// ErrUnknownFile is returned when the key for a file cannot be determined.
+	ErrUnknownFile = errors.New("crypt: unknown file type")
+	// ErrInvalidSize is returned when the buffer size is not a multiple of Block.
+	ErrInvalidSize = errors.New("crypt: invalid buffer size")
+	// ErrInvalidWhence is returned by Seek for unknown whence values.
+	ErrInvalidWhence = errors.New("crypt: invalid whence")
+	// ErrNegativeOffset is returned when the resulting offset is negative.
+	ErrNegativeOffset = errors.New("crypt: negative position")
+	// ErrClosed is returned when using a closed Reader or Writer.
+	ErrClosed = errors.New("crypt: stream is closed")
+	// ErrUnaligned is returned when the data is not aligned to the block size.
+	ErrUnaligned = errors.New("crypt: data is not aligned to the block size")
+	// ErrPadding is returned when the padding of the final block is not zero.
+	ErrPadding = errors.New("crypt: invalid padding")
+	// ErrAllocLimit is returned when the size of the data exceeds the allocation limit.
+	ErrAllocLimit = errors.New("crypt: allocation limit exceeded")
+	// ErrOffsetLimit is returned when the offset exceeds the configured limit.
+	ErrOffsetLimit = errors.New("crypt: offset limit exceeded")
+	// ErrSectionOverrun is returned when reading past the end of the section, see Reader.Section.
+	ErrSectionOverrun = errors.New("crypt: read past the end of section")
+	// ErrNoSection is returned by Writer.EndSection if there's no open section.
+	ErrNoSection = errors.New("crypt: no open section")
+	// ErrChecksum is returned when the stored checksum doesn't match the data.
+	ErrChecksum = errors.New("crypt: checksum mismatch")
+	// ErrVerify is returned by EncryptFile and DecryptFile when the round-trip verification fails.
+	ErrVerify = errors.New("crypt: round-trip verification failed")
+)
+
+var (
+	errSeekUnsupported     = fmt.Errorf("crypt: reader cannot seek: %w", errors.ErrUnsupported)
+	errReadAtUnsupported   = fmt.Errorf("crypt: ReadAt is not supported by the underlying reader: %w", errors.ErrUnsupported)
+	errWriteAtUnsupported  = fmt.Errorf("crypt: WriteAt is not supported by the underlying writer: %w", errors.ErrUnsupported)
+	errTruncateUnsupported = fmt.Errorf("crypt: Truncate is not supported by the underlying writer: %w", errors.ErrUnsupported)
+	errDeadlineUnsupported = fmt.Errorf("crypt: deadlines are not supported by the underlying stream: %w", errors.ErrUnsupported)
+)
+
+// IOError wraps an error returned by the underlying reader, writer or seeker.
+// It allows to distinguish I/O failures from errors related to the data format.
+//
+// Note that io.EOF and io.ErrUnexpectedEOF are never wrapped, since callers compare them directly.
+type IOError struct {
+	Op  string // operation that failed: read, write or seek
+	Err error
+}
+
+func (e *IOError) Error() string {
+	return "crypt: " + e.Op + ": " + e.Err.Error()
+}
+
+func (e *IOError) Unwrap() error {
+	return e.Err
+}
+
+// OffsetError is returned by Reader when reading fails. It records the logical offset in the decoded stream
+// where the error occurred, which helps to locate corrupted data.
+//
+// Note that io.EOF is never wrapped, but io.ErrUnexpectedEOF is, thus errors.Is must be used to check for it.
+type OffsetError struct {
+	Offset int64 // offset in the decoded stream
+	Block  int64 // index of the block that contains the offset
+	Err    error
+}
+
+func (e *OffsetError) Error() string {
+	return fmt.Sprintf("crypt: read at offset 0x%x: %v", e.Offset, e.Err)
+}
+
+func (e *OffsetError) Unwrap() error {
+	return e.Err
+}
+
+// wrapOffset wraps the error in OffsetError, unless it's nil, io.EOF or already wrapped.
+func wrapOffset(off int64, err error) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+	var e *OffsetError
+	if errors.As(err, &e) {
+		return err
+	}
+	return &OffsetError{Offset: off, Block: off / Block, Err: err}
+}
+
+// wrapIO wraps errors returned by the underlying stream in IOError.
+func wrapIO(op string, err error) error {
+	if err == nil || err == io.EOF || err == io.ErrUnexpectedEOF {
+		return err
+	}
+	var e *IOError
+	if errors.As(err, &e) {
+		return err
+	}
+	return &IOError{Op: op, Err: err}
+}
+
+// checkOffset validates the offset against the limit. Negative limit disables the check.
+func checkOffset(off, limit int64) error {
+	if limit > 0 && off > limit {
+		return fmt.Errorf("%w: %#x > %#x", ErrOffsetLimit, off, limit)
+	}
+	return nil
+}
+
+func seek(s io.Seeker, off int64, whence int) (int64, error) {
+	n, err := s.Seek(off, whence)
+	return n, wrapIO("seek", err)
+}
@@ -0,0 +1,107 @@
+package crypt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type errWriter struct {
+	err error
+}
+
+func (w errWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
+
+func TestErrors(t *testing.T) {
+	_, err := NewCipher(maxKeyInd + 1)
+	require.ErrorIs(t, err, ErrInvalidKey)
+
+	err = Encode(make([]byte, Block+1), ThingBin)
+	require.ErrorIs(t, err, ErrInvalidSize)
+	err = Decode(make([]byte, Block+1), ThingBin)
+	require.ErrorIs(t, err, ErrInvalidSize)
+
+	r, err := NewReader(io.MultiReader(strings.NewReader("")), NoKey)
+	require.NoError(t, err)
+	_, err = r.Seek(0, io.SeekStart)
+	require.ErrorIs(t, err, errors.ErrUnsupported)
+	_, err = r.Seek(0, 10)
+	require.ErrorIs(t, err, errors.ErrUnsupported)
+
+	w, err := NewWriter(errWriter{err: os.ErrClosed}, ThingBin)
+	require.NoError(t, err)
+	_, err = w.Write(make([]byte, Block))
+	require.ErrorIs(t, err, os.ErrClosed)
+	var e *IOError
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, "write", e.Op)
+	err = w.WriteU32At(1, 0)
+	require.ErrorIs(t, err, errors.ErrUnsupported)
+}
+
+func TestOffsetLimit(t *testing.T) {
+	f, err := os.CreateTemp("", "crypt-limit-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		f.Close()
+		os.Remove(f.Name())
+	}()
+
+	r, err := NewReader(f, NoKey)
+	require.NoError(t, err)
+	_, err = r.Seek(1<<60, io.SeekStart)
+	require.ErrorIs(t, err, ErrOffsetLimit)
+	_, err = r.ReadAt(make([]byte, Block), 1<<60)
+	require.ErrorIs(t, err, ErrOffsetLimit)
+	err = r.CheckSize(1 << 60)
+	require.ErrorIs(t, err, ErrOffsetLimit)
+	err = r.CheckSize(-1)
+	require.ErrorIs(t, err, ErrOffsetLimit)
+	require.NoError(t, r.CheckSize(1024))
+
+	r.SetMaxOffset(16)
+	_, err = r.Seek(17, io.SeekStart)
+	require.ErrorIs(t, err, ErrOffsetLimit)
+	_, err = r.Seek(16, io.SeekStart)
+	require.NoError(t, err)
+
+	w, err := NewWriter(f, NoKey)
+	require.NoError(t, err)
+	err = w.WriteU64At(1, 1<<60)
+	require.ErrorIs(t, err, ErrOffsetLimit)
+	err = w.WriteU64At(1, -Block)
+	require.ErrorIs(t, err, ErrNegativeOffset)
+	w.SetMaxOffset(-1)
+	require.NoError(t, w.WriteU64At(1, 1<<20))
+}
+
+func TestOffsetError(t *testing.T) {
+	data := make([]byte, 3*Block+3)
+	r, err := NewReader(bytes.NewReader(data), NoKey)
+	require.NoError(t, err)
+	_, err = r.Read(make([]byte, 2*Block+4))
+	require.NoError(t, err)
+	_, err = io.ReadAll(r)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	var e *OffsetError
+	require.ErrorAs(t, err, &e)
+	require.EqualValues(t, 3*Block, e.Offset)
+	require.EqualValues(t, 3, e.Block)
+	require.Equal(t, "crypt: read at offset 0x18: unexpected EOF", err.Error())
+
+	r, err = NewReader(bytes.NewReader(data[:Block]), NoKey)
+	require.NoError(t, err)
+	_, err = r.ReadU64()
+	require.NoError(t, err)
+	_, err = r.ReadU32()
+	require.Equal(t, io.EOF, err)
+}
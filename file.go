@@ -7,7 +7,7 @@ import (
 )
 
 // NewFile creates a file that support encode/decode and seek operations.
-func NewFile(f io.ReadWriteSeeker, key int) (*File, error) {
+func NewFile(f io.ReadWriteSeeker, key Key) (*File, error) {
 	c, err := NewCipher(key)
 	if err != nil {
 		return nil, err
@@ -58,6 +58,7 @@ func (f *File) Buffered() int {
 }
 
 // Flush buffered data to the underlying writer. The data will be aligned to the block size.
+// Flush is a no-op if there's no buffered data.
 func (f *File) Flush() error {
 	if f.mode != fileWrite {
 		return nil
@@ -72,10 +73,11 @@ func (f *File) Flush() error {
 	f.buf = [8]byte{}
 	f.off += int64(Block - f.i)
 	f.i = 0
-	return err
+	return wrapIO("write", err)
 }
 
 // Close flushes the data. See Flush.
+// Same as Flush, it's safe to call Close multiple times.
 func (f *File) Close() error {
 	return f.Flush()
 }
@@ -129,7 +131,7 @@ func (f *File) WriteEmpty() (int64, error) {
 	_, err := f.f.Write(empty[:])
 	off := f.off
 	f.off += Block
-	return off, err
+	return off, wrapIO("write", err)
 }
 
 func (f *File) switchWrite() error {
@@ -148,7 +150,7 @@ func (f *File) switchWrite() error {
 		}
 	}
 	if !end {
-		_, err := f.f.Seek(-Block, io.SeekCurrent)
+		_, err := seek(f.f, -Block, io.SeekCurrent)
 		if err != nil {
 			return err
 		}
@@ -191,7 +193,7 @@ func (f *File) readNext() error {
 	_, err := io.ReadFull(f.f, f.buf[:])
 	if err != nil {
 		f.i = -1
-		return err
+		return wrapIO("read", err)
 	}
 	f.i = 0
 	if f.c != nil {
@@ -255,12 +257,18 @@ func (f *File) ReadAligned(p []byte) (int, error) {
 }
 
 func (f *File) offset() (int64, error) {
-	cur, err := f.f.Seek(0, io.SeekCurrent)
+	cur, err := seek(f.f, 0, io.SeekCurrent)
 	if err != nil {
 		return 0, err
 	}
 	if f.i >= 0 {
-		cur += int64(f.i)
+		if f.mode == fileWrite {
+			// underlying file points to the beginning of the block
+			cur += int64(f.i)
+		} else {
+			// underlying file points to the end of the block
+			cur -= int64(Block - f.i)
+		}
 	}
 	return cur, nil
 }
@@ -270,15 +278,24 @@ func (f *File) Seek(off int64, whence int) (int64, error) {
 	if off == 0 && whence == io.SeekCurrent {
 		return f.offset()
 	}
+	switch whence {
+	case io.SeekStart, io.SeekCurrent, io.SeekEnd:
+	default:
+		return 0, ErrInvalidWhence
+	}
+	if whence == io.SeekStart && off < 0 {
+		return 0, ErrNegativeOffset
+	}
 	if f.mode == fileWrite {
 		if err := f.switchRead(); err != nil {
 			return 0, err
 		}
 	}
-	if whence == io.SeekCurrent {
-		off -= int64(f.Buffered())
+	off, err := seekTarget(f.f, off, whence, f.offset)
+	if err != nil {
+		return 0, err
 	}
-	cur, err := f.f.Seek(off, whence)
+	cur, err := seek(f.f, off, io.SeekStart)
 	f.i = -1
 	if err != nil {
 		return 0, err
@@ -288,7 +305,7 @@ func (f *File) Seek(off int64, whence int) (int64, error) {
 	if rem == 0 {
 		return cur, nil
 	}
-	_, err = f.f.Seek(-rem, io.SeekCurrent)
+	_, err = seek(f.f, -rem, io.SeekCurrent)
 	if err != nil {
 		return 0, err
 	}
@@ -299,3 +316,139 @@ func (f *File) Seek(off int64, whence int) (int64, error) {
 	f.i = int(rem)
 	return cur, nil
 }
+
+// ReadAt implements io.ReaderAt. It decodes the data stored in the underlying file at a given offset,
+// which doesn't need to be aligned to Block. The current offset of the file is not changed.
+//
+// The data buffered by Write is not visible to ReadAt until Flush is called.
+// It requires the underlying file to implement io.ReaderAt.
+func (f *File) ReadAt(p []byte, off int64) (int, error) {
+	ra, ok := f.f.(io.ReaderAt)
+	if !ok {
+		return 0, errReadAtUnsupported
+	}
+	return readAt(ra, f.c, p, off)
+}
+
+// WriteAt implements io.WriterAt. It encodes and writes the data at a given offset, without changing the current offset.
+// Partial blocks at both ends are read, modified and written back, which requires io.ReaderAt in addition to io.WriterAt.
+//
+// The data buffered by Write is not affected by WriteAt, call Flush first if the regions may overlap.
+func (f *File) WriteAt(p []byte, off int64) (int, error) {
+	wa, ok := f.f.(io.WriterAt)
+	if !ok {
+		return 0, errWriteAtUnsupported
+	}
+	ra, _ := f.f.(io.ReaderAt)
+	n, err := writeAt(ra, wa, f.c, p, off)
+	if n != 0 && f.mode == fileRead && f.i >= 0 {
+		// the buffered block may have changed, load it again
+		cur, serr := f.offset()
+		if serr == nil {
+			_, serr = f.Seek(cur, io.SeekStart)
+		}
+		if err == nil {
+			err = serr
+		}
+	}
+	return n, err
+}
+
+// Truncate changes the size of the file. The buffered data is flushed first.
+// If the size is not a multiple of Block, the last block is padded with zeros.
+// If the current offset is past the new size, it is moved to the end of the file.
+// It requires the underlying file to implement Truncate, as well as io.ReaderAt for unaligned sizes.
+func (f *File) Truncate(size int64) error {
+	t, ok := f.f.(interface {
+		Truncate(size int64) error
+	})
+	if !ok {
+		return errTruncateUnsupported
+	}
+	if size < 0 {
+		return ErrNegativeOffset
+	}
+	cur, err := f.offset()
+	if err != nil {
+		return err
+	}
+	if err = f.Flush(); err != nil {
+		return err
+	}
+	f.i = -1
+	f.mode = fileRead
+	if rem := size % Block; rem != 0 {
+		var b [Block]byte
+		if _, err = f.ReadAt(b[:rem], size-rem); err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		if err = t.Truncate(size - rem); err != nil {
+			return wrapIO("truncate", err)
+		}
+		if _, err = f.WriteAt(b[:], size-rem); err != nil {
+			return err
+		}
+	} else if err = t.Truncate(size); err != nil {
+		return wrapIO("truncate", err)
+	}
+	_, err = f.Seek(min(cur, size), io.SeekStart)
+	return err
+}
+
+// Sync flushes the buffered data and commits the underlying file to stable storage, if it implements Sync.
+func (f *File) Sync() error {
+	if err := f.Flush(); err != nil {
+		return err
+	}
+	if s, ok := f.f.(interface{ Sync() error }); ok {
+		return wrapIO("sync", s.Sync())
+	}
+	return nil
+}
+
+// writeAt encodes and writes data at a given offset. It aligns writes to the block size internally,
+// reading partial blocks at both ends from ra. If ra is nil, both the offset and the size must be aligned.
+func writeAt(ra io.ReaderAt, wa io.WriterAt, c *blowfish.Cipher, p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, ErrNegativeOffset
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	rem := int(off % Block)
+	start := off - int64(rem)
+	buf := make([]byte, EncodedSize(int64(rem+len(p))))
+	if rem != 0 || len(p)%Block != 0 {
+		if ra == nil {
+			return 0, ErrUnaligned
+		}
+		if err := readBlockAt(ra, c, buf[:Block], start); err != nil {
+			return 0, err
+		}
+		if last := len(buf) - Block; last != 0 && (rem+len(p))%Block != 0 {
+			if err := readBlockAt(ra, c, buf[last:], start+int64(last)); err != nil {
+				return 0, err
+			}
+		}
+	}
+	copy(buf[rem:], p)
+	if c != nil {
+		for i := 0; i < len(buf); i += Block {
+			b := buf[i : i+Block]
+			c.Encrypt(b, b)
+		}
+	}
+	n, err := wa.WriteAt(buf, start)
+	n = min(max(n-rem, 0), len(p))
+	return n, wrapIO("write", err)
+}
+
+// readBlockAt decodes a single block at a given offset. Blocks past the end of the data are returned as zeros.
+func readBlockAt(ra io.ReaderAt, c *blowfish.Cipher, b []byte, off int64) error {
+	n, err := readAt(ra, c, b, off)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		clear(b[n:])
+		return nil
+	}
+	return err
+}
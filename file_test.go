@@ -172,3 +172,96 @@ func TestFileWriteSeek(t *testing.T) {
 
 	assertData("12deklmnghijc\x00\x00\x00")
 }
+
+func TestFileSeekCurrent(t *testing.T) {
+	const (
+		key     = ThingBin
+		encoded = "\x2c\xc3\x70\x31\x5e\xda\x12\x3c\x49\x28\x27\x6d\x9a\x62\x8e\x94\x95\x36\x9f\xa2\x0d\xd0\x04\xee"
+		decoded = "ROLF\x01\x00\x00\x00\x03\x4d\x75\x64\x3e\x20\x03\x00\x08\x00\x00\x00\x00\x00\x00\x00"
+	)
+	f, err := os.CreateTemp("", "crypt-file-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		f.Close()
+		os.Remove(f.Name())
+	}()
+	_, err = f.WriteString(encoded)
+	require.NoError(t, err)
+	_, err = f.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+
+	r, err := NewFile(f, key)
+	require.NoError(t, err)
+	var buf [3]byte
+	_, err = io.ReadFull(r, buf[:])
+	require.NoError(t, err)
+
+	off, err := r.Seek(0, io.SeekCurrent)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), off)
+
+	_, err = r.Seek(0, 3)
+	require.Error(t, err)
+	_, err = r.Seek(-4, io.SeekCurrent)
+	require.Error(t, err)
+
+	off, err = r.Seek(2, io.SeekCurrent)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), off)
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, decoded[5:], string(out))
+}
+
+func TestFileRandomAccess(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "crypt-file-")
+	require.NoError(t, err)
+	defer f.Close()
+
+	cf, err := NewFile(f, SaveKey)
+	require.NoError(t, err)
+	_, err = cf.WriteAt([]byte("hello, world"), 3)
+	require.NoError(t, err)
+	_, err = cf.WriteAt([]byte("WORLD"), 10)
+	require.NoError(t, err)
+
+	require.Equal(t, int64(2*Block), mustSize(t, f))
+
+	b := make([]byte, 15)
+	_, err = cf.ReadAt(b, 0)
+	require.NoError(t, err)
+	require.Equal(t, "\x00\x00\x00hello, WORLD", string(b))
+
+	// the buffered block is reloaded after WriteAt
+	_, err = cf.Seek(5, io.SeekStart)
+	require.NoError(t, err)
+	_, err = cf.WriteAt([]byte("L"), 5)
+	require.NoError(t, err)
+	b = make([]byte, 3)
+	_, err = io.ReadFull(cf, b)
+	require.NoError(t, err)
+	require.Equal(t, "Llo", string(b))
+
+	require.NoError(t, cf.Truncate(12))
+	require.Equal(t, int64(2*Block), mustSize(t, f))
+	b = make([]byte, 2*Block)
+	_, err = cf.ReadAt(b, 0)
+	require.NoError(t, err)
+	require.Equal(t, "\x00\x00\x00heLlo, WO\x00\x00\x00\x00", string(b))
+
+	pos, err := cf.Seek(0, io.SeekCurrent)
+	require.NoError(t, err)
+	require.EqualValues(t, 8, pos)
+	require.NoError(t, cf.Sync())
+
+	_, err = cf.ReadAt(b, 2*Block)
+	require.Equal(t, io.EOF, err)
+}
+
+func mustSize(t testing.TB, f *os.File) int64 {
+	st, err := f.Stat()
+	require.NoError(t, err)
+	return st.Size()
+}
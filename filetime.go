@@ -0,0 +1,36 @@
+package crypt
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	fileTimeUnit  = uint64(time.Second / 100) // FILETIME intervals per second
+	fileTimeEpoch = 11644473600               // seconds between 1601-01-01 and 1970-01-01
+)
+
+// ReadFileTime reads Windows FILETIME timestamp, which is a number of 100-nanosecond intervals since 1601-01-01 UTC.
+// Zero value is converted to zero time.Time. Returned time is in UTC.
+func (r *Reader) ReadFileTime() (time.Time, error) {
+	v, err := r.ReadU64()
+	if err != nil || v == 0 {
+		return time.Time{}, err
+	}
+	sec := int64(v/fileTimeUnit) - fileTimeEpoch
+	nsec := int64(v%fileTimeUnit) * 100
+	return time.Unix(sec, nsec).UTC(), nil
+}
+
+// WriteFileTime writes Windows FILETIME timestamp. See ReadFileTime.
+// Zero time.Time is written as zero value. Time before 1601-01-01 cannot be represented and returns an error.
+func (w *Writer) WriteFileTime(t time.Time) error {
+	if t.IsZero() {
+		return w.WriteU64(0)
+	}
+	sec := t.Unix() + fileTimeEpoch
+	if sec < 0 || uint64(sec) > (1<<64-1)/fileTimeUnit-1 {
+		return fmt.Errorf("crypt: time %v is out of FILETIME range", t)
+	}
+	return w.WriteU64(uint64(sec)*fileTimeUnit + uint64(t.Nanosecond()/100))
+}
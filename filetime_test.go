@@ -0,0 +1,42 @@
+package crypt
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileTime(t *testing.T) {
+	times := []time.Time{
+		{},
+		time.Date(2001, 2, 3, 4, 5, 6, 700, time.UTC),
+		time.Date(1601, 1, 1, 0, 0, 0, 100, time.UTC),
+		time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf, SaveKey)
+	require.NoError(t, err)
+	for _, v := range times {
+		require.NoError(t, w.WriteFileTime(v))
+	}
+	require.Error(t, w.WriteFileTime(time.Date(1600, 1, 1, 0, 0, 0, 0, time.UTC)))
+	require.NoError(t, w.Close())
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), SaveKey)
+	require.NoError(t, err)
+	for _, exp := range times {
+		v, err := r.ReadFileTime()
+		require.NoError(t, err)
+		require.True(t, exp.Equal(v), "%v != %v", exp, v)
+	}
+
+	// 2001-02-03 04:05:06.0000007
+	r, err = NewReader(bytes.NewReader(buf.Bytes()), SaveKey)
+	require.NoError(t, err)
+	require.NoError(t, r.SkipBlock())
+	v, err := r.ReadU64()
+	require.NoError(t, err)
+	require.EqualValues(t, 0x01c08d967db50507, v)
+}
@@ -0,0 +1,175 @@
+package crypt
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// NewFS creates a file system that decodes files from base on the fly.
+//
+// The key for each file is returned by keyFor. If keyFor is nil, keys are determined by KeyForFile.
+// Files with NoKey, as well as directories, are served as-is. The size of decoded files is the same as in base.
+//
+// Opened files implement io.Seeker and io.ReaderAt if the files in base do, which makes the file system
+// usable with http.FileServer. ReadDir, Stat and Glob are passed to base.
+// Options are applied to readers of all opened files.
+func NewFS(base fs.FS, keyFor func(path string) Key, opts ...Option) fs.FS {
+	if keyFor == nil {
+		keyFor = keyForFileOrNone
+	}
+	return &cryptFS{base: base, keyFor: keyFor, opts: opts}
+}
+
+func keyForFileOrNone(path string) Key {
+	key, ok := KeyForFile(path)
+	if !ok {
+		return NoKey
+	}
+	return key
+}
+
+type cryptFS struct {
+	base   fs.FS
+	keyFor func(path string) Key
+	opts   []Option
+}
+
+var (
+	_ fs.ReadDirFS = (*cryptFS)(nil)
+	_ fs.StatFS    = (*cryptFS)(nil)
+	_ fs.GlobFS    = (*cryptFS)(nil)
+)
+
+// Open implements fs.FS.
+func (c *cryptFS) Open(name string) (fs.File, error) {
+	f, err := c.base.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	key := c.keyFor(name)
+	if key == NoKey {
+		return f, nil
+	}
+	st, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if st.IsDir() {
+		return f, nil
+	}
+	r, err := NewReader(f, key, c.opts...)
+	if err != nil {
+		_ = f.Close()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	r.progTotal = st.Size()
+	return &fsFile{Reader: r, f: f, st: st}, nil
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (c *cryptFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(c.base, name)
+}
+
+// Stat implements fs.StatFS.
+func (c *cryptFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(c.base, name)
+}
+
+// Glob implements fs.GlobFS.
+func (c *cryptFS) Glob(pattern string) ([]string, error) {
+	return fs.Glob(c.base, pattern)
+}
+
+// fsFile is a decoded file returned by cryptFS.
+type fsFile struct {
+	*Reader
+	f  fs.File
+	st fs.FileInfo
+}
+
+// Stat implements fs.File.
+func (f *fsFile) Stat() (fs.FileInfo, error) {
+	return f.st, nil
+}
+
+// Close implements fs.File.
+func (f *fsFile) Close() error {
+	_ = f.Reader.Close()
+	return f.f.Close()
+}
+
+// WriteFS is a file system that allows creating files.
+type WriteFS interface {
+	// Create creates or truncates the named file. The name must be a valid path, see fs.ValidPath.
+	Create(name string) (io.WriteCloser, error)
+	// WriteFile writes data to the named file, creating it if necessary.
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+}
+
+// NewWriteFS creates a WriteFS in a given directory that encodes files on write.
+//
+// The key for each file is returned by keyFor. If keyFor is nil, keys are determined by KeyForFile.
+// Files with NoKey are written as-is. Parent directories are created as needed.
+// Options are applied to each Writer, for example, WithProgress reports progress for each file.
+func NewWriteFS(dir string, keyFor func(path string) Key, opts ...Option) WriteFS {
+	if keyFor == nil {
+		keyFor = keyForFileOrNone
+	}
+	return &dirWriteFS{dir: dir, keyFor: keyFor, opts: opts}
+}
+
+type dirWriteFS struct {
+	dir    string
+	keyFor func(path string) Key
+	opts   []Option
+}
+
+func (d *dirWriteFS) create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	if !fs.ValidPath(name) || name == "." {
+		return nil, &fs.PathError{Op: "create", Path: name, Err: fs.ErrInvalid}
+	}
+	key := d.keyFor(name)
+	c, err := NewCipher(key)
+	if err != nil {
+		return nil, &fs.PathError{Op: "create", Path: name, Err: err}
+	}
+	path := filepath.Join(d.dir, filepath.FromSlash(name))
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return nil, err
+	}
+	if key == NoKey {
+		return f, nil
+	}
+	w := newWriter(f, c, d.opts)
+	w.closer = f
+	return w, nil
+}
+
+// Create implements WriteFS.
+func (d *dirWriteFS) Create(name string) (io.WriteCloser, error) {
+	return d.create(name, 0666)
+}
+
+// WriteFile implements WriteFS.
+func (d *dirWriteFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	w, err := d.create(name, perm)
+	if err != nil {
+		return err
+	}
+	if cw, ok := w.(*Writer); ok {
+		cw.progTotal = EncodedSize(int64(len(data)))
+	}
+	if _, err = w.Write(data); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
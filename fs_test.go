@@ -0,0 +1,93 @@
+package crypt
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFS(t *testing.T) {
+	plain := []byte("map data\x00\x00\x00\x00\x00\x00\x00\x00")
+	enc := append([]byte{}, plain...)
+	require.NoError(t, Encode(enc, MapKey))
+
+	base := fstest.MapFS{
+		"maps/test.map":   {Data: enc},
+		"maps/readme.txt": {Data: []byte("plain text")},
+	}
+	fsys := NewFS(base, nil)
+
+	data, err := fs.ReadFile(fsys, "maps/test.map")
+	require.NoError(t, err)
+	require.Equal(t, plain, data)
+
+	data, err = fs.ReadFile(fsys, "maps/readme.txt")
+	require.NoError(t, err)
+	require.Equal(t, "plain text", string(data))
+
+	f, err := fsys.Open("maps/test.map")
+	require.NoError(t, err)
+	st, err := f.Stat()
+	require.NoError(t, err)
+	require.EqualValues(t, len(plain), st.Size())
+	_, err = f.(io.Seeker).Seek(4, io.SeekStart)
+	require.NoError(t, err)
+	rest, err := io.ReadAll(f)
+	require.NoError(t, err)
+	require.Equal(t, plain[4:], rest)
+	require.NoError(t, f.Close())
+
+	names, err := fs.Glob(fsys, "maps/*.map")
+	require.NoError(t, err)
+	require.Equal(t, []string{"maps/test.map"}, names)
+
+	ents, err := fs.ReadDir(fsys, "maps")
+	require.NoError(t, err)
+	require.Len(t, ents, 2)
+
+	fsys = NewFS(base, func(path string) Key { return ThingBin })
+	data, err = fs.ReadFile(fsys, "maps/test.map")
+	require.NoError(t, err)
+	require.False(t, bytes.Equal(plain, data))
+
+	require.NoError(t, fstest.TestFS(NewFS(base, nil), "maps/test.map", "maps/readme.txt"))
+}
+
+func TestWriteFS(t *testing.T) {
+	dir := t.TempDir()
+	var done, total int64
+	wfs := NewWriteFS(dir, nil, WithProgress(func(d, t int64) {
+		done, total = d, t
+	}))
+
+	require.NoError(t, wfs.WriteFile("maps/test.map", []byte("map data"), 0644))
+	require.EqualValues(t, Block, done)
+	require.EqualValues(t, Block, total)
+	w, err := wfs.Create("readme.txt")
+	require.NoError(t, err)
+	_, err = io.WriteString(w, "plain text")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	_, err = wfs.Create("../escape.map")
+	require.ErrorIs(t, err, fs.ErrInvalid)
+
+	raw, err := os.ReadFile(filepath.Join(dir, "maps", "test.map"))
+	require.NoError(t, err)
+	require.Len(t, raw, Block)
+	require.NotEqual(t, "map data", string(raw))
+
+	fsys := NewFS(os.DirFS(dir), nil)
+	data, err := fs.ReadFile(fsys, "maps/test.map")
+	require.NoError(t, err)
+	require.Equal(t, "map data", string(data))
+	data, err = fs.ReadFile(fsys, "readme.txt")
+	require.NoError(t, err)
+	require.Equal(t, "plain text", string(data))
+}
@@ -0,0 +1,110 @@
+package crypt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+)
+
+// headerField is a struct field with a fixed absolute offset.
+type headerField struct {
+	name string
+	off  int64
+	size int
+	val  reflect.Value
+}
+
+// headerFields returns all fields of the struct with `nox_at` tags.
+func headerFields(v any) ([]headerField, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("crypt: header must be a pointer to struct, got %T", v)
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+	var out []headerField
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		tag, ok := f.Tag.Lookup("nox_at")
+		if !ok {
+			continue
+		}
+		if !f.IsExported() {
+			return nil, fmt.Errorf("crypt: header field %s is not exported", f.Name)
+		}
+		off, err := strconv.ParseInt(tag, 0, 64)
+		if err != nil || off < 0 {
+			return nil, fmt.Errorf("crypt: invalid offset for header field %s: %q", f.Name, tag)
+		}
+		size := binary.Size(rv.Field(i).Interface())
+		if size <= 0 {
+			return nil, fmt.Errorf("crypt: header field %s has unsupported type %s", f.Name, f.Type)
+		}
+		out = append(out, headerField{name: f.Name, off: off, size: size, val: rv.Field(i)})
+	}
+	return out, nil
+}
+
+// ReadHeader decodes struct fields located at fixed absolute offsets.
+// Offsets are set with `nox_at` struct tags (for example, `nox_at:"16"` or `nox_at:"0x10"`),
+// fields without tags are ignored. Fields must have fixed-size types, as accepted by encoding/binary.
+// Values are decoded in little-endian byte order.
+//
+// Typically, r is a Reader, which allows decoding the header without reading the stream sequentially.
+func ReadHeader(r io.ReaderAt, v any) error {
+	fields, err := headerFields(v)
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		buf := make([]byte, f.size)
+		if _, err = r.ReadAt(buf, f.off); err != nil {
+			return fmt.Errorf("crypt: read header field %s: %w", f.name, err)
+		}
+		if err = binary.Read(bytes.NewReader(buf), binary.LittleEndian, f.val.Addr().Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteHeader encodes struct fields and writes them at fixed absolute offsets. See ReadHeader for details.
+//
+// Typically, w is a Writer, which requires writes to be aligned to the block size. Because of this,
+// blocks containing the fields are first read from r (which must be a decoded view of the same data),
+// then fields are updated and the blocks are written back. If r is nil, or the blocks are past the end
+// of the data, the rest of the blocks is filled with zeros.
+func WriteHeader(w io.WriterAt, r io.ReaderAt, v any) error {
+	fields, err := headerFields(v)
+	if err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	start, end := fields[0].off, fields[0].off
+	for _, f := range fields {
+		start = min(start, f.off)
+		end = max(end, f.off+int64(f.size))
+	}
+	start -= start % Block
+	buf := make([]byte, EncodedSize(end-start))
+	if r != nil {
+		if _, err = r.ReadAt(buf, start); err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+	}
+	for _, f := range fields {
+		var b bytes.Buffer
+		if err = binary.Write(&b, binary.LittleEndian, f.val.Interface()); err != nil {
+			return err
+		}
+		copy(buf[f.off-start:], b.Bytes())
+	}
+	_, err = w.WriteAt(buf, start)
+	return err
+}
@@ -0,0 +1,56 @@
+package crypt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testHeader struct {
+	Magic   [4]byte `nox_at:"0"`
+	Version uint32  `nox_at:"4"`
+	Skipped uint32
+	Size    int64   `nox_at:"0x10"`
+	Scale   float32 `nox_at:"25"`
+}
+
+func TestHeader(t *testing.T) {
+	const key = ThingBin
+	buf := &bufferAt{}
+	w, err := NewWriter(buf, key)
+	require.NoError(t, err)
+	_, err = w.WriteEmptyN(4)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("payload"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	h := testHeader{
+		Magic:   [4]byte{'N', 'O', 'X', 0},
+		Version: 2,
+		Skipped: 42,
+		Size:    -1,
+		Scale:   1.5,
+	}
+	r, err := NewReader(bytes.NewReader(buf.buf), key)
+	require.NoError(t, err)
+	err = WriteHeader(w, r, &h)
+	require.NoError(t, err)
+
+	r, err = NewReader(bytes.NewReader(buf.buf), key)
+	require.NoError(t, err)
+	var h2 testHeader
+	err = ReadHeader(r, &h2)
+	require.NoError(t, err)
+	h.Skipped = 0
+	require.Equal(t, h, h2)
+
+	p := make([]byte, 7)
+	_, err = r.ReadAt(p, 4*Block)
+	require.NoError(t, err)
+	require.Equal(t, "payload", string(p))
+
+	err = ReadHeader(r, h2)
+	require.Error(t, err)
+}
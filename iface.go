@@ -0,0 +1,26 @@
+package crypt
+
+import "io"
+
+var (
+	_ io.ReadSeekCloser = (*Reader)(nil)
+	_ io.ReaderAt       = (*Reader)(nil)
+	_ io.WriterTo       = (*Reader)(nil)
+
+	_ io.WriteCloser  = (*Writer)(nil)
+	_ io.WriterAt     = (*Writer)(nil)
+	_ io.ReaderFrom   = (*Writer)(nil)
+	_ io.WriteSeeker  = (*Writer)(nil)
+	_ io.ByteWriter   = (*Writer)(nil)
+	_ io.StringWriter = (*Writer)(nil)
+
+	_ io.ReadWriteSeeker = (*File)(nil)
+	_ io.Closer          = (*File)(nil)
+	_ io.StringWriter    = (*File)(nil)
+	_ io.ReaderAt        = (*File)(nil)
+	_ io.WriterAt        = (*File)(nil)
+
+	_ io.ReadSeeker  = (*SyncReader)(nil)
+	_ io.ReaderAt    = (*SyncReader)(nil)
+	_ io.WriteCloser = (*SyncWriter)(nil)
+)
@@ -0,0 +1,174 @@
+package crypt
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/blowfish"
+)
+
+const (
+	inPlaceChunk  = 1 << 20
+	journalSuffix = ".noxjournal"
+	journalMagic  = "NXCJ"
+	journalHeader = 4 + 4 + 8 + 4 // magic, mode + key, offset, size
+)
+
+// EncryptInPlace encrypts the file with a given key, without making a copy of it.
+// If the file size is not a multiple of Block, the file is padded with zeros.
+//
+// Blocks are transformed in chunks, and the original contents of each chunk is saved to a journal file
+// (with the same name and ".noxjournal" suffix) before being overwritten. If the process is interrupted,
+// calling EncryptInPlace again with the same arguments restores the interrupted chunk and continues the conversion.
+func EncryptInPlace(path string, key Key) error {
+	return convertInPlace(path, key, true)
+}
+
+// DecryptInPlace decrypts the file with a given key, without making a copy of it.
+// The file size must be a multiple of Block. See EncryptInPlace for details.
+func DecryptInPlace(path string, key Key) error {
+	return convertInPlace(path, key, false)
+}
+
+func convertInPlace(path string, key Key, enc bool) error {
+	c, err := NewCipher(key)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var mode uint32
+	if enc {
+		mode = 1
+	}
+	mode |= uint32(key&0xff) << 8
+
+	jpath := path + journalSuffix
+	off, err := recoverJournal(f, jpath, mode)
+	if err != nil {
+		return err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	size := st.Size()
+	if size%Block != 0 {
+		if !enc {
+			return fmt.Errorf("%w: file size %d", ErrUnaligned, size)
+		}
+		size = EncodedSize(size)
+		if err = f.Truncate(size); err != nil {
+			return err
+		}
+	}
+	buf := make([]byte, inPlaceChunk)
+	for ; off < size; off += int64(len(buf)) {
+		buf = buf[:min(int64(cap(buf)), size-off)]
+		if _, err = f.ReadAt(buf, off); err != nil {
+			return err
+		}
+		if err = writeJournal(jpath, mode, off, buf); err != nil {
+			return err
+		}
+		transformBlocks(c, buf, enc)
+		if _, err = f.WriteAt(buf, off); err != nil {
+			return err
+		}
+		if err = f.Sync(); err != nil {
+			return err
+		}
+	}
+	if err = os.Remove(jpath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return f.Close()
+}
+
+func transformBlocks(c *blowfish.Cipher, p []byte, enc bool) {
+	if c == nil {
+		return
+	}
+	for i := 0; i < len(p); i += Block {
+		b := p[i : i+Block]
+		if enc {
+			c.Encrypt(b, b)
+		} else {
+			c.Decrypt(b, b)
+		}
+	}
+}
+
+// writeJournal atomically replaces the journal with the original contents of the chunk.
+func writeJournal(path string, mode uint32, off int64, data []byte) error {
+	buf := make([]byte, journalHeader, journalHeader+len(data)+4)
+	copy(buf, journalMagic)
+	binary.LittleEndian.PutUint32(buf[4:], mode)
+	binary.LittleEndian.PutUint64(buf[8:], uint64(off))
+	binary.LittleEndian.PutUint32(buf[16:], uint32(len(data)))
+	buf = append(buf, data...)
+	buf = binary.LittleEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf))
+
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(buf); err == nil {
+		err = f.Sync()
+	}
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return err
+	}
+	if d, err := os.Open(filepath.Dir(path)); err == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
+	return nil
+}
+
+// recoverJournal restores the chunk saved in the journal (if any) and returns the offset to continue from.
+func recoverJournal(f *os.File, path string, mode uint32) (int64, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if len(data) < journalHeader+4 || string(data[:4]) != journalMagic {
+		return 0, fmt.Errorf("crypt: invalid journal file %q", path)
+	}
+	body, sum := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return 0, fmt.Errorf("crypt: corrupted journal file %q", path)
+	}
+	if m := binary.LittleEndian.Uint32(body[4:]); m != mode {
+		return 0, fmt.Errorf("crypt: journal file %q was created by a different operation", path)
+	}
+	off := int64(binary.LittleEndian.Uint64(body[8:]))
+	chunk := body[journalHeader:]
+	if int(binary.LittleEndian.Uint32(body[16:])) != len(chunk) {
+		return 0, fmt.Errorf("crypt: invalid journal file %q", path)
+	}
+	if _, err = f.WriteAt(chunk, off); err != nil {
+		return 0, err
+	}
+	if err = f.Sync(); err != nil {
+		return 0, err
+	}
+	return off, nil
+}
@@ -0,0 +1,63 @@
+package crypt
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInPlace(t *testing.T) {
+	const key = MapKey
+	path := filepath.Join(t.TempDir(), "test.map")
+	plain := bytes.Repeat([]byte("0123456789abcdef"), inPlaceChunk/16*2+5)
+	plain = append(plain, "xyz"...)
+	err := os.WriteFile(path, plain, 0644)
+	require.NoError(t, err)
+
+	require.NoError(t, EncryptInPlace(path, key))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	exp := make([]byte, EncodedSize(int64(len(plain))))
+	copy(exp, plain)
+	require.Len(t, data, len(exp))
+	exp2 := bytes.Clone(exp)
+	require.NoError(t, Encode(exp2, key))
+	require.Equal(t, exp2, data)
+
+	require.NoError(t, DecryptInPlace(path, key))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, exp, data)
+	_, err = os.Stat(path + journalSuffix)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestInPlaceRecover(t *testing.T) {
+	const key = MapKey
+	path := filepath.Join(t.TempDir(), "test.map")
+	plain := bytes.Repeat([]byte("0123456789abcdef"), inPlaceChunk/16*3)
+	enc := bytes.Clone(plain)
+	require.NoError(t, Encode(enc, key))
+
+	// simulate a crash in the middle of decoding the second chunk
+	broken := bytes.Clone(plain[:inPlaceChunk])
+	broken = append(broken, plain[inPlaceChunk:inPlaceChunk+100]...)
+	broken = append(broken, enc[inPlaceChunk+100:]...)
+	require.NoError(t, os.WriteFile(path, broken, 0644))
+	require.NoError(t, writeJournal(path+journalSuffix, uint32(key)<<8, inPlaceChunk, enc[inPlaceChunk:2*inPlaceChunk]))
+
+	require.Error(t, EncryptInPlace(path, key))
+	require.NoError(t, DecryptInPlace(path, key))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, plain, data)
+}
+
+func TestInPlaceUnaligned(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.map")
+	require.NoError(t, os.WriteFile(path, []byte("123"), 0644))
+	require.ErrorIs(t, DecryptInPlace(path, MapKey), ErrUnaligned)
+}
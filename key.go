@@ -0,0 +1,74 @@
+package crypt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Key is an index of the crypto key in the Nox key table.
+type Key int
+
+// Constants for known crypto keys.
+const (
+	KeyNone     = Key(-1)
+	KeySoundSet = Key(5)
+	KeyThing    = Key(7)
+	KeyGameData = Key(8)
+	KeyModifier = Key(13)
+	KeyMap      = Key(19)
+	KeyMonster  = Key(23)
+	KeySave     = Key(27)
+)
+
+// Old names for known crypto keys.
+const (
+	NoKey       = KeyNone
+	SoundSetBin = KeySoundSet
+	ThingBin    = KeyThing
+	GameDataBin = KeyGameData
+	ModifierBin = KeyModifier
+	MonsterBin  = KeyMonster
+	MapKey      = KeyMap
+	SaveKey     = KeySave
+)
+
+var keyNames = map[Key]string{
+	KeyNone:     "none",
+	KeySoundSet: "soundset",
+	KeyThing:    "thing",
+	KeyGameData: "gamedata",
+	KeyModifier: "modifier",
+	KeyMap:      "map",
+	KeyMonster:  "monster",
+	KeySave:     "save",
+}
+
+// Valid checks if the key exists in the key table.
+func (k Key) Valid() bool {
+	return k == KeyNone || (k >= 0 && k <= maxKeyInd)
+}
+
+// String returns a name of a known key, or its index otherwise.
+func (k Key) String() string {
+	if name, ok := keyNames[k]; ok {
+		return name
+	}
+	return strconv.Itoa(int(k))
+}
+
+// ParseKey parses a key name, as returned by Key.String. Names are case-insensitive.
+// Key indexes are accepted as well.
+func ParseKey(s string) (Key, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	for k, v := range keyNames {
+		if v == name {
+			return k, nil
+		}
+	}
+	v, err := strconv.Atoi(name)
+	if err != nil || !Key(v).Valid() {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidKey, s)
+	}
+	return Key(v), nil
+}
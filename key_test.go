@@ -0,0 +1,33 @@
+package crypt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKey(t *testing.T) {
+	for k := range keyNames {
+		t.Run(k.String(), func(t *testing.T) {
+			require.True(t, k.Valid())
+			p, err := ParseKey(k.String())
+			require.NoError(t, err)
+			require.Equal(t, k, p)
+		})
+	}
+	require.Equal(t, "map", MapKey.String())
+	require.Equal(t, "3", Key(3).String())
+
+	k, err := ParseKey(" Thing")
+	require.NoError(t, err)
+	require.Equal(t, KeyThing, k)
+	k, err = ParseKey("3")
+	require.NoError(t, err)
+	require.Equal(t, Key(3), k)
+
+	_, err = ParseKey("unknown")
+	require.ErrorIs(t, err, ErrInvalidKey)
+	_, err = ParseKey("-2")
+	require.ErrorIs(t, err, ErrInvalidKey)
+	require.False(t, Key(maxKeyInd+1).Valid())
+}
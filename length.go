@@ -0,0 +1,51 @@
+package crypt
+
+import "fmt"
+
+// WriteLengthHeader reserves a block for the length of the data that follows it.
+// The length is written by Close, once it's known, and doesn't include the padding of the final block.
+// Use Reader.ReadLengthHeader to read the data back. It requires the underlying writer to implement io.WriterAt.
+func (w *Writer) WriteLengthHeader() error {
+	if w.lenHdr >= 0 {
+		return fmt.Errorf("%w: length header already written", ErrInvalidSize)
+	}
+	if w.at == nil {
+		return errWriteAtUnsupported
+	}
+	off, err := w.WriteEmpty()
+	if err != nil {
+		return err
+	}
+	w.lenHdr = off
+	return nil
+}
+
+// ReadLengthHeader reads the length block written by Writer.WriteLengthHeader and limits the Reader to that
+// many bytes, see Limit. Thus, the padding of the final block is never returned to the caller.
+func (r *Reader) ReadLengthHeader() (int64, error) {
+	if err := r.Align(); err != nil {
+		return 0, err
+	}
+	v, err := r.ReadU64()
+	if err != nil {
+		return 0, err
+	}
+	n := int64(v)
+	if n < 0 {
+		return 0, fmt.Errorf("%w: negative length %d", ErrInvalidSize, n)
+	}
+	if err = r.checkLimit(n); err != nil {
+		return 0, err
+	}
+	if err = r.CheckSize(n); err != nil {
+		return 0, err
+	}
+	r.size = n
+	r.Limit(n)
+	return n, nil
+}
+
+// Size returns the length of the data read by ReadLengthHeader, or -1 if it wasn't called.
+func (r *Reader) Size() int64 {
+	return r.size
+}
@@ -0,0 +1,36 @@
+package crypt
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLengthHeader(t *testing.T) {
+	buf := &bufferAt{}
+	w, err := NewWriter(buf, MapKey)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteLengthHeader())
+	require.ErrorIs(t, w.WriteLengthHeader(), ErrInvalidSize)
+	_, err = w.Write([]byte("hello, world"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.Len(t, buf.buf, 3*Block)
+
+	r, err := NewReader(bytes.NewReader(buf.buf), MapKey)
+	require.NoError(t, err)
+	require.EqualValues(t, -1, r.Size())
+	n, err := r.ReadLengthHeader()
+	require.NoError(t, err)
+	require.EqualValues(t, 12, n)
+	require.EqualValues(t, 12, r.Size())
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "hello, world", string(data))
+
+	w, err = NewWriter(bytes.NewBuffer(nil), MapKey)
+	require.NoError(t, err)
+	require.Error(t, w.WriteLengthHeader())
+}
@@ -0,0 +1,37 @@
+package crypt
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Placeholder describes a region reserved by Writer.WriteEmpty or similar methods.
+type Placeholder struct {
+	Name   string `json:"name,omitempty"`
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+}
+
+func (w *Writer) track(name string, off, size int64) {
+	if !w.TrackPlaceholders {
+		return
+	}
+	w.manifest = append(w.manifest, Placeholder{Name: name, Offset: off, Size: size})
+}
+
+// Manifest returns all placeholders reserved since the last Reset. It requires TrackPlaceholders to be set.
+func (w *Writer) Manifest() []Placeholder {
+	return append([]Placeholder(nil), w.manifest...)
+}
+
+// WriteManifest writes the list of placeholders as JSON, for example, to a sidecar file.
+// It allows external tools to patch the placeholders without parsing the format. See Manifest.
+func (w *Writer) WriteManifest(dst io.Writer) error {
+	list := w.manifest
+	if list == nil {
+		list = []Placeholder{}
+	}
+	enc := json.NewEncoder(dst)
+	enc.SetIndent("", "\t")
+	return enc.Encode(list)
+}
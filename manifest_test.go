@@ -0,0 +1,48 @@
+package crypt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifest(t *testing.T) {
+	w, err := NewWriter(bytes.NewBuffer(nil), ThingBin, WithPlaceholders(true))
+	require.NoError(t, err)
+	_, err = w.WriteEmpty()
+	require.NoError(t, err)
+	_, err = w.Write([]byte("abc"))
+	require.NoError(t, err)
+	_, err = w.WriteEmptyNamed("index", 3)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	require.Equal(t, []Placeholder{
+		{Offset: 0, Size: Block},
+		{Name: "index", Offset: 2 * Block, Size: 3 * Block},
+	}, w.Manifest())
+
+	var sb strings.Builder
+	require.NoError(t, w.WriteManifest(&sb))
+	require.Equal(t, `[
+	{
+		"offset": 0,
+		"size": 8
+	},
+	{
+		"name": "index",
+		"offset": 16,
+		"size": 24
+	}
+]
+`, sb.String())
+
+	w.Reset(bytes.NewBuffer(nil))
+	require.Empty(t, w.Manifest())
+	w.TrackPlaceholders = false
+	_, err = w.WriteEmpty()
+	require.NoError(t, err)
+	require.Empty(t, w.Manifest())
+}
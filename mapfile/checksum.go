@@ -0,0 +1,89 @@
+package mapfile
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	crypt "github.com/opennox/noxcrypt"
+)
+
+// ErrChecksum is returned when the checksum stored in the map header doesn't match the map data.
+var ErrChecksum = crypt.ErrChecksum
+
+// Checksum reads the map from r and returns the checksum stored in the header, and the one calculated from the data.
+//
+// The checksum is calculated with crypt.UpdateCRC over each decoded block that follows the header, see crypt.NewCRC.
+func Checksum(r io.Reader) (stored, actual uint32, _ error) {
+	cr, err := crypt.NewReader(r, crypt.MapKey)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cr.Close()
+	magic, err := cr.ReadU32()
+	if err != nil {
+		return 0, 0, unexpected(err)
+	} else if magic != Magic {
+		return 0, 0, fmt.Errorf("%w: %#08x", ErrInvalidMagic, magic)
+	}
+	stored, err = cr.ReadU32()
+	if err != nil {
+		return 0, 0, unexpected(err)
+	}
+	h := crypt.NewCRC()
+	if _, err = io.Copy(h, cr); err != nil {
+		return 0, 0, err
+	}
+	return stored, h.Sum32(), nil
+}
+
+// VerifyMapCRC checks the checksum stored in the header of a given map file.
+// It returns ErrChecksum if the checksum doesn't match the map data.
+func VerifyMapCRC(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	stored, actual, err := Checksum(f)
+	if err != nil {
+		return err
+	}
+	if stored != actual {
+		return fmt.Errorf("%w: stored %#08x, actual %#08x", ErrChecksum, stored, actual)
+	}
+	return nil
+}
+
+// FixMapCRC recalculates the checksum of a given map file and rewrites it in the header.
+// The file is only modified if the stored checksum doesn't match.
+func FixMapCRC(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	stored, actual, err := Checksum(f)
+	if err != nil {
+		return err
+	}
+	if stored == actual {
+		return nil
+	}
+	var hdr [HeaderSize]byte
+	if _, err = f.ReadAt(hdr[:], 0); err != nil {
+		return err
+	}
+	if err = crypt.Decode(hdr[:], crypt.MapKey); err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(hdr[4:], actual)
+	if err = crypt.Encode(hdr[:], crypt.MapKey); err != nil {
+		return err
+	}
+	if _, err = f.WriteAt(hdr[:], 0); err != nil {
+		return err
+	}
+	return f.Close()
+}
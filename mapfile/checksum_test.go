@@ -0,0 +1,42 @@
+package mapfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	crypt "github.com/opennox/noxcrypt"
+)
+
+func TestMapCRC(t *testing.T) {
+	data := writeTestMap(t, testSections)
+
+	plain := append([]byte{}, data...)
+	require.NoError(t, crypt.Decode(plain, crypt.MapKey))
+	h := crypt.NewCRC()
+	h.Write(plain[HeaderSize:])
+	exp := h.Sum32()
+
+	path := filepath.Join(t.TempDir(), "test.map")
+	require.NoError(t, os.WriteFile(path, data, 0644))
+
+	err := VerifyMapCRC(path)
+	require.ErrorIs(t, err, ErrChecksum)
+
+	require.NoError(t, FixMapCRC(path))
+	require.NoError(t, VerifyMapCRC(path))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	stored, actual, err := Checksum(f)
+	require.NoError(t, err)
+	require.Equal(t, exp, stored)
+	require.Equal(t, exp, actual)
+
+	fixed, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, data[HeaderSize:], fixed[HeaderSize:])
+}
@@ -0,0 +1,206 @@
+// Package mapfile provides low-level access to sections of Nox map files.
+//
+// The map file is encrypted with crypt.MapKey and has the following layout:
+//
+//	header:  magic (uint32, 0xFADEFACE), checksum (uint32)
+//	section: name length (uint8), name (null-terminated), padding to the block size,
+//	         payload size (uint64), payload, padding to the block size
+//
+// Sections follow each other until the end of the file, or until a section with an empty name.
+package mapfile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	crypt "github.com/opennox/noxcrypt"
+)
+
+// Magic is the magic value of Nox map files.
+const Magic = 0xFADEFACE
+
+// HeaderSize is the size of the map file header.
+const HeaderSize = crypt.Block
+
+// ErrInvalidMagic is returned when the file is not a Nox map.
+var ErrInvalidMagic = errors.New("mapfile: invalid magic")
+
+// Section describes a named section of the map.
+type Section struct {
+	Name   string // name of the section, for example "MapInfo"
+	Offset int64  // offset of the section payload in the decoded stream
+	Size   int64  // size of the section payload
+}
+
+// Sections creates an iterator over map sections. The reader must be positioned at the beginning of the file.
+// The header is read by the first call to Next.
+//
+//	it := mapfile.Sections(r)
+//	for it.Next() {
+//		sec := it.Section()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+func Sections(r *crypt.Reader) *Iter {
+	return &Iter{r: r}
+}
+
+// Iter iterates over map sections. See Sections.
+type Iter struct {
+	r      *crypt.Reader
+	header bool
+	cur    Section
+	sec    *crypt.Section
+	err    error
+	done   bool
+}
+
+// Next skips the rest of the current section and advances to the next one.
+// It returns false at the end of the file, or when an error occurs. See Err.
+func (it *Iter) Next() bool {
+	if it.done {
+		return false
+	}
+	if err := it.next(); err != nil {
+		it.done = true
+		if err != io.EOF {
+			it.err = err
+		}
+		return false
+	}
+	return true
+}
+
+func (it *Iter) next() error {
+	r := it.r
+	if !it.header {
+		it.header = true
+		magic, err := r.ReadU32()
+		if err != nil {
+			return unexpected(err)
+		} else if magic != Magic {
+			return fmt.Errorf("%w: %#08x", ErrInvalidMagic, magic)
+		}
+		if _, err = r.ReadU32(); err != nil {
+			return unexpected(err)
+		}
+	}
+	if it.sec != nil {
+		if err := it.sec.End(); err != nil {
+			return err
+		}
+		it.sec = nil
+	}
+	if err := r.Align(); err != nil {
+		return err
+	}
+	n, err := r.ReadU8()
+	if err != nil {
+		return err
+	} else if n == 0 {
+		return io.EOF
+	}
+	name := make([]byte, n)
+	if err = r.ReadFixed(name); err != nil {
+		return err
+	}
+	if err = r.Align(); err != nil {
+		return unexpected(err)
+	}
+	size, err := r.ReadI64()
+	if err != nil {
+		return unexpected(err)
+	}
+	off := r.Offset()
+	sec, err := r.Section(size)
+	if err != nil {
+		return fmt.Errorf("mapfile: section %q: %w", name, err)
+	}
+	it.sec = sec
+	it.cur = Section{Name: strings.TrimRight(string(name), "\x00"), Offset: off, Size: size}
+	return nil
+}
+
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Section returns the current section.
+func (it *Iter) Section() Section {
+	return it.cur
+}
+
+// Payload returns a reader for the payload of the current section. Reads are limited to the section size.
+// The reader is only valid until the next call to Next.
+func (it *Iter) Payload() io.Reader {
+	if it.sec == nil {
+		return eofReader{}
+	}
+	return payloadReader{r: it.r, sec: it.sec}
+}
+
+type eofReader struct{}
+
+func (eofReader) Read([]byte) (int, error) {
+	return 0, io.EOF
+}
+
+// payloadReader reports truncated sections as io.ErrUnexpectedEOF.
+type payloadReader struct {
+	r   *crypt.Reader
+	sec *crypt.Section
+}
+
+func (p payloadReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if err == io.EOF && p.sec.Remaining() > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iter) Err() error {
+	return it.err
+}
+
+// WriteHeader writes the map header with a given checksum.
+func WriteHeader(w *crypt.Writer, checksum uint32) error {
+	if err := w.WriteU32(Magic); err != nil {
+		return err
+	}
+	return w.WriteU32(checksum)
+}
+
+// WriteSection writes a section with a given name and payload.
+func WriteSection(w *crypt.Writer, name string, payload []byte) error {
+	if name == "" || len(name)+1 > 0xff {
+		return fmt.Errorf("mapfile: invalid section name %q", name)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := w.WriteU8(uint8(len(name) + 1)); err != nil {
+		return err
+	}
+	if err := w.WriteString0(name); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := w.WriteI64(int64(len(payload))); err != nil {
+		return err
+	}
+	if _, err := w.Write(payload); err != nil {
+		return err
+	}
+	return w.Flush()
+}
@@ -0,0 +1,91 @@
+package mapfile
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	crypt "github.com/opennox/noxcrypt"
+)
+
+type testSection struct {
+	name string
+	data []byte
+}
+
+var testSections = []testSection{
+	{"MapInfo", []byte("map info data")},
+	{"WallMap", bytes.Repeat([]byte{1, 2, 3}, 100)},
+	{"Empty", nil},
+	{"ObjectData", []byte("objects")},
+}
+
+func writeTestMap(t testing.TB, sections []testSection) []byte {
+	var buf bytes.Buffer
+	w, err := crypt.NewWriter(&buf, crypt.MapKey)
+	require.NoError(t, err)
+	require.NoError(t, WriteHeader(w, 0))
+	for _, s := range sections {
+		require.NoError(t, WriteSection(w, s.name, s.data))
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestSections(t *testing.T) {
+	data := writeTestMap(t, testSections)
+	for _, read := range []bool{false, true} {
+		r, err := crypt.NewReader(bytes.NewReader(data), crypt.MapKey)
+		require.NoError(t, err)
+		it := Sections(r)
+		var got []testSection
+		for it.Next() {
+			sec := it.Section()
+			require.EqualValues(t, len(testSections[len(got)].data), sec.Size)
+			s := testSection{name: sec.Name}
+			if read {
+				s.data, err = io.ReadAll(it.Payload())
+				require.NoError(t, err)
+				if len(s.data) == 0 {
+					s.data = nil
+				}
+			} else {
+				s.data = testSections[len(got)].data
+			}
+			got = append(got, s)
+		}
+		require.NoError(t, it.Err())
+		require.Equal(t, testSections, got)
+		require.False(t, it.Next())
+	}
+}
+
+func TestSectionsMagic(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := crypt.NewWriter(&buf, crypt.MapKey)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteU64(0))
+	require.NoError(t, w.Close())
+
+	r, err := crypt.NewReader(&buf, crypt.MapKey)
+	require.NoError(t, err)
+	it := Sections(r)
+	require.False(t, it.Next())
+	require.ErrorIs(t, it.Err(), ErrInvalidMagic)
+}
+
+func TestSectionsTruncated(t *testing.T) {
+	data := writeTestMap(t, testSections[:2])
+	r, err := crypt.NewReader(bytes.NewReader(data[:len(data)-crypt.Block]), crypt.MapKey)
+	require.NoError(t, err)
+	it := Sections(r)
+	require.True(t, it.Next())
+	require.Equal(t, "MapInfo", it.Section().Name)
+	require.True(t, it.Next())
+	require.Equal(t, "WallMap", it.Section().Name)
+	_, err = io.ReadAll(it.Payload())
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.False(t, it.Next())
+}
@@ -0,0 +1,236 @@
+package crypt
+
+import (
+	"fmt"
+	"io"
+)
+
+// MixedReader reads streams that consist of both plain and encrypted regions,
+// for example files with unencrypted headers followed by encrypted data.
+//
+// The reader starts in plain mode, which passes data from the underlying reader as-is.
+// Use SetEncrypted to switch modes. Each region is aligned to Block, same as written by MixedWriter:
+// the rest of the current partial block is skipped as padding before switching to a different mode.
+type MixedReader struct {
+	src  io.Reader
+	cr   *Reader
+	enc  bool
+	crc  noxCRC
+	pos  int64 // offset of the current region
+	base int64
+}
+
+// NewMixedReader creates a new MixedReader with a given key. See MixedReader for details.
+func NewMixedReader(r io.Reader, key Key, opts ...Option) (*MixedReader, error) {
+	c, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	cr := newReader(r, c, opts)
+	// never consume data beyond the current block, it may belong to the plain region
+	cr.SetReadAhead(-1)
+	mr := &MixedReader{src: r, cr: cr}
+	mr.crc.Reset()
+	return mr, nil
+}
+
+// Encrypted reports whether the reader is in encrypted mode.
+func (r *MixedReader) Encrypted() bool {
+	return r.enc
+}
+
+// SetEncrypted switches the reader between plain and encrypted modes. The padding of the current region is skipped.
+// It returns ErrUnaligned if the encrypted block was only partially read from the underlying reader.
+func (r *MixedReader) SetEncrypted(enc bool) error {
+	if enc == r.enc {
+		return nil
+	}
+	if enc {
+		if n := int(r.pos % Block); n != 0 {
+			var pad [Block]byte
+			m, err := io.ReadFull(r.src, pad[:Block-n])
+			r.crc.Write(pad[:m])
+			r.pos += int64(m)
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			if err != nil {
+				return wrapIO("read", err)
+			}
+		}
+		r.base = r.Offset()
+		r.cr.Reset(r.src)
+		r.cr.crc = r.crc.Sum32()
+		r.enc = true
+		return nil
+	}
+	if r.cr.fill != 0 {
+		return fmt.Errorf("%w: switching to plain mode at offset %d", ErrUnaligned, r.Offset())
+	}
+	if _, err := r.cr.Discard(r.cr.Buffered()); err != nil {
+		return err
+	}
+	r.base = r.Offset()
+	r.crc.Reset()
+	r.crc.crc = r.cr.CRC()
+	r.pos = 0
+	r.enc = false
+	return nil
+}
+
+// CRC returns the CRC checksum of the data read so far, including both plain and encrypted regions,
+// same as MixedWriter.CRC. In particular, the CRC trailer of the final encrypted region covers all regions.
+func (r *MixedReader) CRC() uint32 {
+	if r.enc {
+		return r.cr.CRC()
+	}
+	return r.crc.Sum32()
+}
+
+// Offset returns the current offset in the stream.
+func (r *MixedReader) Offset() int64 {
+	if r.enc {
+		return r.base + r.cr.Offset()
+	}
+	return r.base + r.pos
+}
+
+// Reader returns the decrypting Reader used in encrypted mode. It can be used for reading typed values.
+// Offsets reported by it are relative to the beginning of the current encrypted region.
+// Seeking the returned Reader is not supported.
+func (r *MixedReader) Reader() *Reader {
+	return r.cr
+}
+
+// Read implements io.Reader.
+func (r *MixedReader) Read(p []byte) (int, error) {
+	if r.enc {
+		return r.cr.Read(p)
+	}
+	n, err := r.src.Read(p)
+	r.crc.Write(p[:n])
+	r.pos += int64(n)
+	return n, wrapIO("read", err)
+}
+
+// MixedWriter writes streams that consist of both plain and encrypted regions.
+// It is the counterpart of MixedReader.
+//
+// The writer starts in plain mode, which passes data to the underlying writer as-is.
+// Use SetEncrypted to switch modes. Each region is aligned to Block: the last partial block
+// is padded with zeros before switching to a different mode. MixedReader skips this padding,
+// thus the same sequence of SetEncrypted calls on both sides reads the data back.
+//
+// The CRC is calculated over the plain data in both regions, see CRC.
+type MixedWriter struct {
+	dst  io.Writer
+	cw   *Writer
+	enc  bool
+	crc  noxCRC
+	pos  int64 // offset of the current region
+	base int64
+	err  error
+}
+
+// NewMixedWriter creates a new MixedWriter with a given key. See MixedWriter for details.
+func NewMixedWriter(w io.Writer, key Key, opts ...Option) (*MixedWriter, error) {
+	c, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	mw := &MixedWriter{dst: w, cw: newWriter(w, c, opts)}
+	mw.crc.Reset()
+	return mw, nil
+}
+
+// Encrypted reports whether the writer is in encrypted mode.
+func (w *MixedWriter) Encrypted() bool {
+	return w.enc
+}
+
+// SetEncrypted switches the writer between plain and encrypted modes. The current region is padded to Block.
+func (w *MixedWriter) SetEncrypted(enc bool) error {
+	if enc == w.enc {
+		return nil
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	w.base = w.Offset()
+	if enc {
+		w.cw.Reset(w.dst)
+		w.cw.crc = w.crc.Sum32()
+	} else {
+		w.crc.Reset()
+		w.crc.crc = w.cw.CRC()
+		w.pos = 0
+	}
+	w.enc = enc
+	return nil
+}
+
+// Offset returns the current offset in the stream.
+func (w *MixedWriter) Offset() int64 {
+	if w.enc {
+		return w.base + w.cw.Written()
+	}
+	return w.base + w.pos
+}
+
+// Writer returns the encrypting Writer used in encrypted mode. It can be used for writing typed values.
+// Offsets reported by it are relative to the beginning of the current encrypted region.
+func (w *MixedWriter) Writer() *Writer {
+	return w.cw
+}
+
+// CRC returns the CRC checksum of the data written so far, including both plain and encrypted regions.
+// The data is processed in blocks, the same way as Writer.CRC does.
+func (w *MixedWriter) CRC() uint32 {
+	if w.enc {
+		return w.cw.CRC()
+	}
+	return w.crc.Sum32()
+}
+
+// Write implements io.Writer.
+func (w *MixedWriter) Write(p []byte) (int, error) {
+	if w.enc {
+		return w.cw.Write(p)
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.dst.Write(p)
+	w.crc.Write(p[:n])
+	w.pos += int64(n)
+	if err != nil {
+		w.err = wrapIO("write", err)
+	}
+	return n, w.err
+}
+
+// Flush pads the current region to Block and flushes it to the underlying writer.
+func (w *MixedWriter) Flush() error {
+	if w.enc {
+		return w.cw.Flush()
+	}
+	if n := int(w.pos % Block); n != 0 {
+		var pad [Block]byte
+		if _, err := w.Write(pad[:Block-n]); err != nil {
+			return err
+		}
+	}
+	return w.err
+}
+
+// Close flushes the current region. It doesn't close the underlying writer.
+//
+// If the last region is encrypted, the inner Writer is closed, which writes the final padding block
+// and the CRC trailer, if they are enabled with WithPKCS7 and WithCRCTrailer. These options are only
+// supported for the final encrypted region, since the Reader needs EOF to detect them.
+func (w *MixedWriter) Close() error {
+	if w.enc {
+		return w.cw.Close()
+	}
+	return w.Flush()
+}
@@ -0,0 +1,138 @@
+package crypt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMixedReader(t *testing.T) {
+	body := []byte("encrypted body!!")
+	enc, err := EncodePadded(MapKey, body)
+	require.NoError(t, err)
+	var src bytes.Buffer
+	src.WriteString("MAGIC\x00\x00\x00")
+	src.Write(enc)
+	src.WriteString("tail")
+
+	r, err := NewMixedReader(&src, MapKey)
+	require.NoError(t, err)
+	require.False(t, r.Encrypted())
+
+	hdr := make([]byte, 5)
+	_, err = io.ReadFull(r, hdr)
+	require.NoError(t, err)
+	require.Equal(t, "MAGIC", string(hdr))
+
+	require.NoError(t, r.SetEncrypted(true))
+	require.True(t, r.Encrypted())
+	v, err := r.Reader().ReadU64()
+	require.NoError(t, err)
+	require.EqualValues(t, 2*Block, r.Offset())
+	require.Equal(t, "encrypte", string(binary.LittleEndian.AppendUint64(nil, v)))
+
+	b := make([]byte, 3)
+	_, err = io.ReadFull(r, b)
+	require.NoError(t, err)
+	require.Equal(t, "d b", string(b))
+	require.NoError(t, r.SetEncrypted(false))
+	require.EqualValues(t, 3*Block, r.Offset())
+
+	rest, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "tail", string(rest))
+	require.EqualValues(t, 3*Block+4, r.Offset())
+
+	r, err = NewMixedReader(bytes.NewReader([]byte("MAG")), MapKey)
+	require.NoError(t, err)
+	_, err = io.ReadFull(r, hdr[:3])
+	require.NoError(t, err)
+	require.ErrorIs(t, r.SetEncrypted(true), io.ErrUnexpectedEOF)
+}
+
+func TestMixedWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewMixedWriter(&buf, MapKey)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("MAGIC"))
+	require.NoError(t, err)
+	require.NoError(t, w.SetEncrypted(true))
+	require.EqualValues(t, Block, w.Offset())
+	require.NoError(t, w.Writer().WriteU32(0x1234))
+	require.NoError(t, w.SetEncrypted(false))
+	_, err = w.Write([]byte("tail"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.EqualValues(t, 3*Block, w.Offset())
+
+	plain := []byte("MAGIC\x00\x00\x00\x34\x12\x00\x00\x00\x00\x00\x00tail\x00\x00\x00\x00")
+	h := NewCRC()
+	h.Write(plain)
+	require.Equal(t, h.Sum32(), w.CRC())
+
+	data := buf.Bytes()
+	require.Equal(t, plain[:Block], data[:Block])
+	require.Equal(t, plain[2*Block:], data[2*Block:])
+	require.NoError(t, Decode(data[Block:2*Block], MapKey))
+	require.Equal(t, plain, data)
+}
+
+func TestMixedRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewMixedWriter(&buf, MapKey)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("MAGIC"))
+	require.NoError(t, err)
+	require.NoError(t, w.SetEncrypted(true))
+	require.NoError(t, w.Writer().WriteU32(0x1234))
+	require.NoError(t, w.SetEncrypted(false))
+	_, err = w.Write([]byte("tail"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	r, err := NewMixedReader(bytes.NewReader(buf.Bytes()), MapKey)
+	require.NoError(t, err)
+	hdr := make([]byte, 5)
+	_, err = io.ReadFull(r, hdr)
+	require.NoError(t, err)
+	require.Equal(t, "MAGIC", string(hdr))
+	require.NoError(t, r.SetEncrypted(true))
+	v, err := r.Reader().ReadU32()
+	require.NoError(t, err)
+	require.EqualValues(t, 0x1234, v)
+	require.NoError(t, r.SetEncrypted(false))
+	tail := make([]byte, 4)
+	_, err = io.ReadFull(r, tail)
+	require.NoError(t, err)
+	require.Equal(t, "tail", string(tail))
+	require.Equal(t, w.Offset()-Block+4, r.Offset())
+	require.Equal(t, w.CRC(), r.CRC())
+}
+
+func TestMixedWriterClose(t *testing.T) {
+	opts := []Option{WithPKCS7(true), WithCRCTrailer(true)}
+	var buf bytes.Buffer
+	w, err := NewMixedWriter(&buf, MapKey, opts...)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("MAGIC"))
+	require.NoError(t, err)
+	require.NoError(t, w.SetEncrypted(true))
+	_, err = w.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, w.Close())
+	require.Equal(t, 3*Block, buf.Len())
+
+	r, err := NewMixedReader(bytes.NewReader(buf.Bytes()), MapKey, opts...)
+	require.NoError(t, err)
+	hdr := make([]byte, 5)
+	_, err = io.ReadFull(r, hdr)
+	require.NoError(t, err)
+	require.NoError(t, r.SetEncrypted(true))
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(data))
+}
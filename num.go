@@ -0,0 +1,53 @@
+package crypt
+
+import "reflect"
+
+// Number is a constraint for fixed-size numeric types supported by ReadNum and WriteNum.
+type Number interface {
+	~int8 | ~uint8 | ~int16 | ~uint16 | ~int32 | ~uint32 | ~int64 | ~uint64 | ~float32 | ~float64
+}
+
+// ReadNum reads a little-endian number of a given type.
+func ReadNum[T Number](r *Reader) (T, error) {
+	var v T
+	switch reflect.TypeOf(v).Kind() {
+	case reflect.Int8, reflect.Uint8:
+		b, err := r.ReadU8()
+		return T(b), err
+	case reflect.Int16, reflect.Uint16:
+		b, err := r.ReadU16()
+		return T(b), err
+	case reflect.Int32, reflect.Uint32:
+		b, err := r.ReadU32()
+		return T(b), err
+	case reflect.Int64, reflect.Uint64:
+		b, err := r.ReadU64()
+		return T(b), err
+	case reflect.Float32:
+		b, err := r.ReadF32()
+		return T(b), err
+	case reflect.Float64:
+		b, err := r.ReadF64()
+		return T(b), err
+	}
+	panic("unreachable")
+}
+
+// WriteNum writes a little-endian number of a given type.
+func WriteNum[T Number](w *Writer, v T) error {
+	switch reflect.TypeOf(v).Kind() {
+	case reflect.Int8, reflect.Uint8:
+		return w.WriteU8(uint8(v))
+	case reflect.Int16, reflect.Uint16:
+		return w.WriteU16(uint16(v))
+	case reflect.Int32, reflect.Uint32:
+		return w.WriteU32(uint32(v))
+	case reflect.Int64, reflect.Uint64:
+		return w.WriteU64(uint64(v))
+	case reflect.Float32:
+		return w.WriteF32(float32(v))
+	case reflect.Float64:
+		return w.WriteF64(float64(v))
+	}
+	panic("unreachable")
+}
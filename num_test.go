@@ -0,0 +1,72 @@
+package crypt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testEnum int16
+
+func testNum[T Number](t *testing.T, v T) {
+	t.Helper()
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf, ThingBin)
+	require.NoError(t, err)
+	err = WriteNum(w, v)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	r, err := NewReader(buf, ThingBin)
+	require.NoError(t, err)
+	got, err := ReadNum[T](r)
+	require.NoError(t, err)
+	require.Equal(t, v, got)
+}
+
+func TestNum(t *testing.T) {
+	testNum(t, int8(-3))
+	testNum(t, uint8(200))
+	testNum(t, int16(-300))
+	testNum(t, testEnum(-2))
+	testNum(t, uint16(60000))
+	testNum(t, int32(-70000))
+	testNum(t, uint32(0xdeadbeef))
+	testNum(t, int64(-1<<40))
+	testNum(t, uint64(1<<63))
+	testNum(t, float32(1.5))
+	testNum(t, float64(-2.25))
+
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf, NoKey)
+	require.NoError(t, err)
+	require.NoError(t, WriteNum(w, float32(0.5)))
+	require.NoError(t, w.Close())
+	r, err := NewReader(buf, NoKey)
+	require.NoError(t, err)
+	v, err := r.ReadU32()
+	require.NoError(t, err)
+	require.Equal(t, uint32(0x3f000000), v)
+}
+
+func TestFloat(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf, ThingBin)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteF32(1.5))
+	require.NoError(t, w.WriteF32(-0.25))
+	require.NoError(t, w.WriteF64(3.125))
+	require.NoError(t, w.Close())
+
+	r, err := NewReader(buf, ThingBin)
+	require.NoError(t, err)
+	f, err := r.ReadU32()
+	require.NoError(t, err)
+	require.EqualValues(t, 0x3fc00000, f)
+	v32, err := r.ReadF32()
+	require.NoError(t, err)
+	require.Equal(t, float32(-0.25), v32)
+	v64, err := r.ReadF64()
+	require.NoError(t, err)
+	require.Equal(t, 3.125, v64)
+}
@@ -0,0 +1,48 @@
+package crypt
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+)
+
+// WriteObject encodes the object with encoding.BinaryMarshaler and writes it with 32 bit length prefix.
+func (w *Writer) WriteObject(v any) error {
+	m, ok := v.(encoding.BinaryMarshaler)
+	if !ok {
+		return fmt.Errorf("crypt: WriteObject requires encoding.BinaryMarshaler, got %T", v)
+	}
+	data, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if uint64(len(data)) > math.MaxUint32 {
+		return fmt.Errorf("%w: object size %d", ErrInvalidSize, len(data))
+	}
+	if err = w.WriteU32(uint32(len(data))); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// ReadObject reads the data written by Writer.WriteObject and decodes it with encoding.BinaryUnmarshaler.
+// The size of the object is limited by MaxAlloc, see ReadBytes.
+func (r *Reader) ReadObject(v any) error {
+	u, ok := v.(encoding.BinaryUnmarshaler)
+	if !ok {
+		return fmt.Errorf("crypt: ReadObject requires encoding.BinaryUnmarshaler, got %T", v)
+	}
+	n, err := r.ReadU32()
+	if err != nil {
+		return err
+	}
+	if uint64(n) > math.MaxInt32 {
+		return fmt.Errorf("%w: object size %d", ErrAllocLimit, n)
+	}
+	data, err := r.ReadBytes(int(n))
+	if err != nil {
+		return err
+	}
+	return u.UnmarshalBinary(data)
+}
@@ -0,0 +1,47 @@
+package crypt
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testObject struct {
+	data string
+}
+
+func (o *testObject) MarshalBinary() ([]byte, error) {
+	if o.data == "" {
+		return nil, errors.New("empty object")
+	}
+	return []byte(o.data), nil
+}
+
+func (o *testObject) UnmarshalBinary(p []byte) error {
+	o.data = string(p)
+	return nil
+}
+
+func TestObject(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf, SaveKey)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteObject(&testObject{data: "first object"}))
+	require.NoError(t, w.WriteObject(&url.URL{Scheme: "https", Host: "opennox.github.io"}))
+	require.Error(t, w.WriteObject(&testObject{}))
+	require.Error(t, w.WriteObject(1))
+	require.NoError(t, w.Close())
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), SaveKey)
+	require.NoError(t, err)
+	var o testObject
+	require.NoError(t, r.ReadObject(&o))
+	require.Equal(t, "first object", o.data)
+	r.SetMaxAlloc(4)
+	var u url.URL
+	require.ErrorIs(t, r.ReadObject(&u), ErrAllocLimit)
+	require.Error(t, r.ReadObject(o))
+}
@@ -0,0 +1,174 @@
+package crypt
+
+// Option configures a Reader or a Writer.
+// Options that are not applicable to a given type are ignored.
+type Option func(o *options)
+
+type options struct {
+	r *Reader
+	w *Writer
+
+	progress func(done, total int64) // for helpers that don't use Reader or Writer directly
+}
+
+func (o *options) apply(opts []Option) {
+	for _, fnc := range opts {
+		fnc(o)
+	}
+}
+
+// WithNoZero sets Writer.NoZero flag.
+func WithNoZero(v bool) Option {
+	return func(o *options) {
+		if o.w != nil {
+			o.w.NoZero = v
+		}
+	}
+}
+
+// WithStrictClose sets Writer.StrictClose flag.
+func WithStrictClose(v bool) Option {
+	return func(o *options) {
+		if o.w != nil {
+			o.w.StrictClose = v
+		}
+	}
+}
+
+// WithRandomPadding enables deterministic random padding on Writer. See Writer.SetRandomPadding.
+func WithRandomPadding(seed int64) Option {
+	return func(o *options) {
+		if o.w != nil {
+			o.w.SetRandomPadding(seed)
+		}
+	}
+}
+
+// WithPadding sets the padding byte for Writer. See Writer.SetPadding.
+func WithPadding(b byte) Option {
+	return func(o *options) {
+		if o.w != nil {
+			o.w.SetPadding(b)
+		}
+	}
+}
+
+// WithPKCS7 enables or disables PKCS#7 padding for both Reader and Writer. See Reader.PKCS7 and Writer.SetPKCS7Padding.
+// Disabling it restores the default zero padding of the Writer.
+func WithPKCS7(v bool) Option {
+	return func(o *options) {
+		if o.r != nil {
+			o.r.PKCS7 = v
+		}
+		if o.w != nil {
+			if v {
+				o.w.SetPKCS7Padding()
+			} else if o.w.pad == padPKCS7 {
+				// restore the default zero padding
+				o.w.SetPadding(0)
+			}
+		}
+	}
+}
+
+// WithCRCTrailer sets Reader.CRCTrailer and Writer.CRCTrailer flags.
+func WithCRCTrailer(v bool) Option {
+	return func(o *options) {
+		if o.r != nil {
+			o.r.CRCTrailer = v
+		}
+		if o.w != nil {
+			o.w.CRCTrailer = v
+		}
+	}
+}
+
+// WithCRCMode sets the CRC mode for Reader and Writer. See Writer.SetCRCMode.
+func WithCRCMode(m CRCMode) Option {
+	return func(o *options) {
+		if o.r != nil {
+			o.r.SetCRCMode(m)
+		}
+		if o.w != nil {
+			o.w.SetCRCMode(m)
+		}
+	}
+}
+
+// WithPlaceholders sets Writer.TrackPlaceholders flag.
+func WithPlaceholders(v bool) Option {
+	return func(o *options) {
+		if o.w != nil {
+			o.w.TrackPlaceholders = v
+		}
+	}
+}
+
+// WithVerifyPadding sets Reader.VerifyPadding flag.
+func WithVerifyPadding(v bool) Option {
+	return func(o *options) {
+		if o.r != nil {
+			o.r.VerifyPadding = v
+		}
+	}
+}
+
+// WithStrictEOF sets Reader.StrictEOF flag.
+func WithStrictEOF(v bool) Option {
+	return func(o *options) {
+		if o.r != nil {
+			o.r.StrictEOF = v
+		}
+	}
+}
+
+// WithMaxAlloc sets the allocation limit for Reader. See Reader.SetMaxAlloc.
+func WithMaxAlloc(n int) Option {
+	return func(o *options) {
+		if o.r != nil {
+			o.r.SetMaxAlloc(n)
+		}
+	}
+}
+
+// WithReadAhead sets the read-ahead size for Reader. See Reader.SetReadAhead.
+func WithReadAhead(n int) Option {
+	return func(o *options) {
+		if o.r != nil {
+			o.r.SetReadAhead(n)
+		}
+	}
+}
+
+// WithAllocator sets the allocator for Reader helpers. See Reader.SetAllocator.
+func WithAllocator(a Allocator) Option {
+	return func(o *options) {
+		if o.r != nil {
+			o.r.SetAllocator(a)
+		}
+	}
+}
+
+// WithTiming enables time measurement for Reader and Writer Stats.
+func WithTiming(on bool) Option {
+	return func(o *options) {
+		if o.r != nil {
+			o.r.SetTiming(on)
+		}
+		if o.w != nil {
+			o.w.SetTiming(on)
+		}
+	}
+}
+
+// WithMaxOffset sets the offset limit for Reader and Writer. See Reader.SetMaxOffset and Writer.SetMaxOffset.
+func WithMaxOffset(n int64) Option {
+	return func(o *options) {
+		if o.r != nil {
+			o.r.SetMaxOffset(n)
+		}
+		if o.w != nil {
+			o.w.SetMaxOffset(n)
+		}
+	}
+}
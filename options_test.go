@@ -0,0 +1,46 @@
+package crypt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptions(t *testing.T) {
+	opts := []Option{
+		WithNoZero(true),
+		WithStrictClose(true),
+		WithVerifyPadding(true),
+		WithMaxAlloc(16),
+		WithMaxOffset(32),
+		WithStrictEOF(true),
+		WithReadAhead(100),
+	}
+	w, err := NewWriter(bytes.NewBuffer(nil), ThingBin, opts...)
+	require.NoError(t, err)
+	require.True(t, w.NoZero)
+	require.True(t, w.StrictClose)
+	require.Equal(t, int64(32), w.MaxOffset())
+
+	r, err := NewReader(bytes.NewReader(nil), ThingBin, opts...)
+	require.NoError(t, err)
+	require.True(t, r.VerifyPadding)
+	require.True(t, r.StrictEOF)
+	require.Equal(t, 16, r.MaxAlloc())
+	require.Equal(t, int64(32), r.MaxOffset())
+	require.Equal(t, 104, r.ReadAhead())
+}
+
+func TestWithPKCS7(t *testing.T) {
+	w, err := NewWriter(bytes.NewBuffer(nil), ThingBin, WithPKCS7(true), WithPKCS7(false))
+	require.NoError(t, err)
+	require.Equal(t, padZero, w.pad)
+	w, err = NewWriter(bytes.NewBuffer(nil), ThingBin, WithPadding(0xff), WithPKCS7(false))
+	require.NoError(t, err)
+	require.Equal(t, padByte, w.pad)
+
+	r, err := NewReader(bytes.NewReader(nil), ThingBin, WithPKCS7(true), WithPKCS7(false))
+	require.NoError(t, err)
+	require.False(t, r.PKCS7)
+}
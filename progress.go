@@ -0,0 +1,56 @@
+package crypt
+
+// WithProgress sets a progress callback for Reader and Writer, as well as helpers that accept options,
+// such as Recrypt, ReadFile, WriteFile and NewFS. See Reader.SetProgress and Writer.SetProgress.
+func WithProgress(fnc func(done, total int64)) Option {
+	return func(o *options) {
+		o.progress = fnc
+		if o.r != nil {
+			o.r.SetProgress(fnc, 0)
+		}
+		if o.w != nil {
+			o.w.SetProgress(fnc, 0)
+		}
+	}
+}
+
+// SetProgress sets a callback that is called each time the Reader decodes a group of blocks.
+// It receives the number of bytes decoded so far and the total size of the stream, or -1 if it's unknown.
+// If total is zero, the size of the stream is determined by seeking the underlying reader, if possible.
+// Setting a nil callback disables progress reporting.
+func (r *Reader) SetProgress(fnc func(done, total int64), total int64) {
+	r.progress = fnc
+	r.progTotal = total
+}
+
+func (r *Reader) reportProgress() {
+	total := r.progTotal
+	if total == 0 {
+		if r.progSize == 0 {
+			r.progSize = -1
+			if rem, ok := r.remaining(); ok {
+				// remaining counts from the read position, but decoded blocks are already reported as done
+				r.progSize = r.stats.Blocks*Block + rem - int64(r.Buffered()) - int64(r.hn)
+			}
+		}
+		total = r.progSize
+	}
+	r.progress(r.stats.Blocks*Block, total)
+}
+
+// SetProgress sets a callback that is called each time the Writer writes encoded data to the underlying writer.
+// It receives the number of bytes written so far and the expected total size, or -1 if it's unknown.
+// Setting a nil callback disables progress reporting.
+func (w *Writer) SetProgress(fnc func(done, total int64), total int64) {
+	w.progress = fnc
+	w.progTotal = total
+}
+
+func (w *Writer) reportProgress(n int) {
+	w.progDone += int64(n)
+	total := w.progTotal
+	if total <= 0 {
+		total = -1
+	}
+	w.progress(w.progDone, total)
+}
@@ -0,0 +1,77 @@
+package crypt
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type progressLog struct {
+	done, total []int64
+}
+
+func (p *progressLog) report(done, total int64) {
+	p.done = append(p.done, done)
+	p.total = append(p.total, total)
+}
+
+func (p *progressLog) check(t testing.TB, total int64) {
+	t.Helper()
+	require.NotEmpty(t, p.done)
+	for i, v := range p.total {
+		require.Equal(t, total, v)
+		if i > 0 {
+			require.Greater(t, p.done[i], p.done[i-1])
+		}
+	}
+	if total > 0 {
+		require.Equal(t, total, p.done[len(p.done)-1])
+	}
+}
+
+func TestProgress(t *testing.T) {
+	data := bytes.Repeat([]byte("progress"), 3*copyChunk/Block)
+	enc, err := EncodePadded(MapKey, append([]byte{}, data...))
+	require.NoError(t, err)
+
+	var p progressLog
+	r, err := NewReader(bytes.NewReader(enc), MapKey, WithProgress(p.report))
+	require.NoError(t, err)
+	_, err = io.Copy(io.Discard, r)
+	require.NoError(t, err)
+	p.check(t, int64(len(enc)))
+
+	p = progressLog{}
+	r.Reset(bytes.NewBuffer(enc))
+	_, err = io.Copy(io.Discard, r)
+	require.NoError(t, err)
+	p.check(t, -1)
+
+	p = progressLog{}
+	w, err := NewWriter(io.Discard, MapKey, WithProgress(p.report))
+	require.NoError(t, err)
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	p.check(t, -1)
+	require.Equal(t, int64(len(data)), p.done[len(p.done)-1])
+
+	p = progressLog{}
+	_, err = Recrypt(io.Discard, bytes.NewReader(enc), MapKey, SaveKey, WithProgress(p.report))
+	require.NoError(t, err)
+	p.check(t, int64(len(enc)))
+
+	path := filepath.Join(t.TempDir(), "test.map")
+	p = progressLog{}
+	require.NoError(t, WriteFile(path, MapKey, data, WithProgress(p.report)))
+	p.check(t, int64(len(data)))
+
+	p = progressLog{}
+	out, err := ReadFile(path, MapKey, WithProgress(p.report))
+	require.NoError(t, err)
+	require.Equal(t, data, out)
+	p.check(t, int64(len(data)))
+}
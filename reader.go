@@ -2,101 +2,697 @@ package crypt
 
 import (
 	"encoding/binary"
-	"errors"
+	"fmt"
 	"io"
+	"math"
+	"strconv"
+	"time"
 
 	"golang.org/x/crypto/blowfish"
 )
 
 // NewReader creates a decoder with a given key and byte stream.
-func NewReader(r io.Reader, key int) (*Reader, error) {
+func NewReader(r io.Reader, key Key, opts ...Option) (*Reader, error) {
 	c, err := NewCipher(key)
 	if err != nil {
 		return nil, err
 	}
+	return newReader(r, c, opts), nil
+}
+
+// NewReaderBytes is similar to NewReader, but uses custom key material instead of the Nox key table.
+func NewReaderBytes(r io.Reader, key []byte, opts ...Option) (*Reader, error) {
+	c, err := NewCipherBytes(key)
+	if err != nil {
+		return nil, err
+	}
+	return newReader(r, c, opts), nil
+}
+
+func newReader(r io.Reader, c *blowfish.Cipher, opts []Option) *Reader {
 	rd := &Reader{c: c}
+	o := options{r: rd}
+	o.apply(opts)
 	rd.Reset(r)
-	return rd, nil
+	return rd
 }
 
+// Reader decodes the data from an underlying reader.
+//
+// Reader is not safe for concurrent use, see SyncReader. The only exception is ReadAt,
+// which doesn't use any internal state and can be called from multiple goroutines.
 type Reader struct {
-	r   io.Reader
-	s   io.Seeker
-	c   *blowfish.Cipher
-	buf [Block]byte
-	i   int
+	r         io.Reader
+	s         io.Seeker
+	at        io.ReaderAt
+	c         *blowfish.Cipher
+	buf       []byte // decoded blocks in buf[i:n], followed by hn held back bytes and a partially read block
+	i         int
+	n         int
+	hn        int // number of decoded bytes held back to strip PKCS#7 padding, see PKCS7
+	fill      int // number of bytes in a partially read block
+	crc       uint32
+	crcPrev   uint32 // crc before the last decoded block, used to check the trailer in CRCCipher mode
+	crcMode   CRCMode
+	crcLost   bool // some blocks were skipped, so crc cannot be verified
+	maxAlloc  int
+	ahead     int
+	maxOff    int64
+	closed    bool
+	closer    io.Closer // set by Open
+	progress  func(done, total int64)
+	progTotal int64
+	progSize  int64 // size of the stream for progress reporting, determined by seeking
+	trace     BlockTraceFunc
+	pos       int64     // logical position, relative to the beginning of the reader
+	lim       int64     // end of the current section (in terms of pos), or -1
+	size      int64     // data size from the length header, or -1, see ReadLengthHeader
+	dump      io.Writer // see DumpReader
+	allocr    Allocator
+	stats     Stats
+	timing    bool
+	// VerifyPadding enables padding checks on the final block.
+	// If Align skips bytes of the last block and reaches EOF, these bytes must be all zeros,
+	// as written by Writer.Flush by default. Otherwise, ErrPadding is returned instead of io.EOF.
+	// Note that files written with Writer.NoZero may fail this check.
+	VerifyPadding bool
+	// StrictEOF enables strict EOF semantics. Read never returns a non-zero count together with an error:
+	// the error is returned by the next call instead. Fixed-size reads, like ReadU32, return io.ErrUnexpectedEOF
+	// if the stream ends in the middle of the value, instead of io.EOF.
+	StrictEOF bool
+	// PKCS7 enables PKCS#7 padding, as written by Writer.SetPKCS7Padding. The padding in the final block
+	// is verified and stripped, so Read returns exactly the bytes that were written.
+	// Invalid padding results in ErrPadding instead of io.EOF.
+	// ReadAt and Section offsets are not affected.
+	PKCS7 bool
+	// CRCTrailer enables verification of the CRC trailer block, as written by Writer.CRCTrailer.
+	// The trailer is never returned to the caller. If the checksum of the data doesn't match the trailer,
+	// ErrChecksum is returned instead of io.EOF. The check is skipped if the stream wasn't read sequentially,
+	// for example, after Seek.
+	CRCTrailer bool
 }
 
 func (r *Reader) Reset(s io.Reader) {
 	r.r = s
 	r.s, _ = s.(io.Seeker)
-	r.i = -1
+	r.at, _ = s.(io.ReaderAt)
+	r.i, r.n = 0, 0
+	r.fill, r.hn = 0, 0
+	r.closed = false
+	r.closer = nil
+	r.pos = 0
+	r.lim = -1
+	r.size = -1
+	r.crc = ZeroCRC
+	r.crcLost = false
+	r.progSize = 0
+	r.stats = Stats{}
 }
 
-func (r *Reader) Buffered() int {
-	if r.i < 0 || r.i >= Block {
-		return 0
+// Close implements io.Closer. It doesn't close the underlying reader, unless the Reader was created by Open.
+// After Close, all read and seek operations fail with ErrClosed, until Reset is called.
+// It is safe to call Close multiple times.
+func (r *Reader) Close() error {
+	r.closed = true
+	r.i, r.n = 0, 0
+	r.fill, r.hn = 0, 0
+	if c := r.closer; c != nil {
+		r.closer = nil
+		return c.Close()
 	}
-	return Block - r.i
+	return nil
 }
 
-func (r *Reader) readNext() error {
-	_, err := io.ReadFull(r.r, r.buf[:])
+// ResetCRC resets CRC internal state.
+func (r *Reader) ResetCRC() {
+	r.crc = ZeroCRC
+	r.crcLost = false
+}
+
+// SetCRCMode selects whether CRC covers decoded data (CRCPlain, the default) or the encoded data
+// read from the underlying reader (CRCCipher). See Writer.SetCRCMode.
+func (r *Reader) SetCRCMode(m CRCMode) {
+	r.crcMode = m
+}
+
+// CRC returns the checksum of all blocks decoded so far, same as Writer.CRC for the same data.
+// Since whole blocks are decoded, it may include the data that is buffered, but not yet read.
+// In CRCPlain mode, blocks held back for CRCTrailer and PKCS7 are not included until EOF is reached.
+func (r *Reader) CRC() uint32 {
+	return r.crc
+}
+
+// VerifyCRC compares the checksum of the data decoded so far with the expected one and returns ErrChecksum
+// if they don't match. It also fails if some blocks were skipped, for example, by Seek.
+func (r *Reader) VerifyCRC(exp uint32) error {
+	if r.crcLost {
+		return fmt.Errorf("%w: stream wasn't read sequentially", ErrChecksum)
+	}
+	if r.crc != exp {
+		return fmt.Errorf("%w: expected %#08x, got %#08x", ErrChecksum, exp, r.crc)
+	}
+	return nil
+}
+
+// SetReadAhead sets the number of bytes the Reader tries to read and decode at once.
+// Larger values reduce the number of calls to the underlying reader, but the Reader may consume
+// more data from it than requested by the caller.
+// Zero resets it to DefaultReadAhead, negative value disables read-ahead and reads one block at a time.
+func (r *Reader) SetReadAhead(n int) {
+	r.ahead = n
+}
+
+// ReadAhead returns current read-ahead size in bytes. See SetReadAhead.
+func (r *Reader) ReadAhead() int {
+	if r.ahead == 0 {
+		return DefaultReadAhead
+	} else if r.ahead < 0 {
+		return Block
+	}
+	return int(EncodedSize(int64(r.ahead)))
+}
+
+// SetMaxAlloc sets the maximal size of a single allocation made by read helpers,
+// for example, when reading length-prefixed data. This prevents corrupted or malicious files
+// from causing huge allocations. Zero resets the limit to DefaultMaxAlloc, negative value disables it.
+func (r *Reader) SetMaxAlloc(n int) {
+	r.maxAlloc = n
+}
+
+// MaxAlloc returns current allocation limit. See SetMaxAlloc.
+func (r *Reader) MaxAlloc() int {
+	if r.maxAlloc == 0 {
+		return DefaultMaxAlloc
+	}
+	return r.maxAlloc
+}
+
+// SetAllocator sets the allocator for read helpers that return newly allocated data.
+// If nil, memory is allocated with make.
+func (r *Reader) SetAllocator(a Allocator) {
+	r.allocr = a
+}
+
+// checkAlloc checks if the allocation of a given size is allowed.
+func (r *Reader) checkAlloc(n int) error {
+	if n < 0 {
+		return fmt.Errorf("%w: negative size %d", ErrAllocLimit, n)
+	}
+	if limit := r.MaxAlloc(); limit > 0 && n > limit {
+		return fmt.Errorf("%w: %d > %d", ErrAllocLimit, n, limit)
+	}
+	return nil
+}
+
+// alloc allocates a buffer of a given size, respecting the allocation limit.
+func (r *Reader) alloc(n int) ([]byte, error) {
+	if err := r.checkAlloc(n); err != nil {
+		return nil, err
+	}
+	if r.allocr != nil {
+		return r.allocr.Alloc(n), nil
+	}
+	return make([]byte, n), nil
+}
+
+// ReadBytes reads and returns exactly n bytes. The buffer is allocated with the Allocator, if set.
+//
+// It fails before allocating the buffer if n exceeds MaxAlloc (ErrAllocLimit),
+// or if the size of the remaining data is known and is smaller than n (io.ErrUnexpectedEOF).
+// This prevents corrupted length fields from causing huge allocations.
+func (r *Reader) ReadBytes(n int) ([]byte, error) {
+	if r.closed {
+		return nil, ErrClosed
+	}
+	if err := r.checkAlloc(n); err != nil {
+		return nil, err
+	}
+	if err := r.checkLimit(int64(n)); err != nil {
+		return nil, err
+	}
+	if rem, ok := r.remaining(); ok && int64(n) > rem {
+		return nil, wrapOffset(r.pos, fmt.Errorf("%w: need %d bytes, %d remaining", io.ErrUnexpectedEOF, n, rem))
+	}
+	b, err := r.alloc(n)
 	if err != nil {
+		return nil, err
+	}
+	if _, err = io.ReadFull(r, b); err != nil {
+		if err == io.EOF && n != 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, wrapOffset(r.pos, err)
+	}
+	return b, nil
+}
+
+// ReadFixed reads a fixed-size field, filling p completely.
+// Unlike Read, it returns io.ErrUnexpectedEOF if the stream ends before p is filled, even if no bytes were read.
+func (r *Reader) ReadFixed(p []byte) error {
+	if err := r.checkLimit(int64(len(p))); err != nil {
 		return err
 	}
-	r.i = 0
-	if r.c != nil {
-		r.c.Decrypt(r.buf[:], r.buf[:])
+	n, err := io.ReadFull(r, p)
+	if err == io.EOF || (err == nil && n != len(p)) {
+		err = io.ErrUnexpectedEOF
+	}
+	return wrapOffset(r.pos, err)
+}
+
+// remaining returns the number of bytes left in the stream, if the underlying reader can seek.
+func (r *Reader) remaining() (int64, bool) {
+	if r.s == nil {
+		return 0, false
+	}
+	n, err := seekRemaining(r.s)
+	if err != nil {
+		return 0, false
+	}
+	return n + int64(r.Buffered()) + int64(r.hn) + int64(r.fill), true
+}
+
+// seekRemaining returns the number of bytes left in the stream after the current position, preserving it.
+func seekRemaining(s io.Seeker) (int64, error) {
+	cur, err := s.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	end, err := s.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = s.Seek(cur, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return end - cur, nil
+}
+
+// SetMaxOffset sets the maximal offset that can be accessed with Seek, ReadAt or CheckSize.
+// This prevents bogus offsets from corrupted headers from causing huge I/O operations.
+// Zero resets the limit to DefaultMaxOffset, negative value disables it.
+func (r *Reader) SetMaxOffset(n int64) {
+	r.maxOff = n
+}
+
+// MaxOffset returns current offset limit. See SetMaxOffset.
+func (r *Reader) MaxOffset() int64 {
+	if r.maxOff == 0 {
+		return DefaultMaxOffset
+	}
+	return r.maxOff
+}
+
+// CheckSize validates the size of the data that follows the current position,
+// usually decoded from a section header. It returns ErrOffsetLimit if the end of the data
+// exceeds the offset limit.
+func (r *Reader) CheckSize(n int64) error {
+	if n < 0 {
+		return fmt.Errorf("%w: negative size %d", ErrOffsetLimit, n)
+	}
+	cur := int64(0)
+	if r.s != nil {
+		var err error
+		cur, err = r.offset()
+		if err != nil {
+			return err
+		}
+	}
+	return checkOffset(cur+n, r.MaxOffset())
+}
+
+// DebugState returns a human-readable description of the internal state of the Reader.
+// It is intended for bug reports and the format may change at any time.
+func (r *Reader) DebugState() string {
+	off := "unknown"
+	if r.s != nil {
+		if cur, err := r.offset(); err == nil {
+			off = strconv.FormatInt(cur, 10)
+		}
+	}
+	return fmt.Sprintf("crypt.Reader{offset: %s, buffered: %d, readAhead: %d, encrypted: %v, verifyPadding: %v, maxAlloc: %d, maxOffset: %d, seeker: %v, readerAt: %v}",
+		off, r.Buffered(), r.ReadAhead(), r.c != nil, r.VerifyPadding, r.MaxAlloc(), r.MaxOffset(), r.s != nil, r.at != nil)
+}
+
+// SetReadDeadline sets the read deadline on the underlying reader, for example net.Conn.
+// If the deadline is exceeded in the middle of the block, the next read will continue from the same position.
+func (r *Reader) SetReadDeadline(t time.Time) error {
+	d, ok := r.r.(interface {
+		SetReadDeadline(t time.Time) error
+	})
+	if !ok {
+		return errDeadlineUnsupported
+	}
+	return d.SetReadDeadline(t)
+}
+
+// Buffered returns the number of decoded bytes that can be read from the current buffer.
+func (r *Reader) Buffered() int {
+	return r.n - r.i
+}
+
+// avail returns the number of buffered bytes that can be consumed without crossing the end of the section.
+func (r *Reader) avail() int {
+	n := r.n - r.i
+	if r.lim >= 0 {
+		n = int(min(int64(n), r.lim-r.pos))
+	}
+	return n
+}
+
+// checkLimit checks if n bytes can be read without crossing the end of the section.
+func (r *Reader) checkLimit(n int64) error {
+	if r.lim >= 0 && n > r.lim-r.pos {
+		return wrapOffset(r.pos, fmt.Errorf("%w: need %d bytes, %d remaining", ErrSectionOverrun, n, r.lim-r.pos))
+	}
+	return nil
+}
+
+// Offset returns the current position in the decoded stream, similar to Writer.Written.
+// It accounts for buffered data and is not affected by read-ahead. The position is relative
+// to the beginning of the stream passed to Reset. After a successful Seek with any whence value it
+// becomes absolute, same as the offset returned by Seek.
+// Unlike Seek, it works for any underlying reader.
+func (r *Reader) Offset() int64 {
+	return r.pos
+}
+
+// readNext reads and decodes the next block, and appends it to the buffer.
+func (r *Reader) readNext() error {
+	return r.readBlocks(Block)
+}
+
+// readBlocks reads and decodes at least one block, up to size bytes, and appends them to the buffer.
+// If the underlying reader fails in the middle of the block (for example, due to a deadline), the data is kept
+// and the next call will continue reading the same block.
+func (r *Reader) readBlocks(size int) error {
+	size = max(Block, size-size%Block)
+	if r.i == r.n && r.n != 0 {
+		// buffer is drained, move held back and partial blocks to the beginning
+		copy(r.buf, r.buf[r.n:r.n+r.hn+r.fill])
+		r.i, r.n = 0, 0
+	}
+	if len(r.buf) < r.n+r.hn+size {
+		// keep blocks aligned in the buffer
+		base := r.i - r.i%Block
+		buf := make([]byte, max(2*len(r.buf), r.n+r.hn-base+size))
+		copy(buf, r.buf[base:r.n+r.hn+r.fill])
+		r.buf = buf
+		r.n -= base
+		r.i -= base
+	}
+	b := r.buf[r.n+r.hn : r.n+r.hn+size]
+	var err error
+	t := startTimer(r.timing)
+	for r.fill < Block && err == nil {
+		var n int
+		n, err = r.r.Read(b[r.fill:])
+		r.stats.Calls++
+		r.fill += n
+	}
+	stopTimer(&r.stats.IOTime, t)
+	if r.fill < Block {
+		if err == io.EOF && r.fill == 0 && r.hn != 0 {
+			return r.releaseHeld()
+		}
+		if err == io.EOF && r.fill != 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		return wrapIO("read", err)
+	}
+	// complete blocks are returned first, the error (if any) will be returned by the next read
+	n := r.fill - r.fill%Block
+	r.fill -= n
+	b = b[:n]
+	base := r.pos + int64(r.n-r.i+r.hn)
+	if r.crcMode == CRCCipher {
+		r.crcPrev = updateCRCBlocks(r.crc, b[:n-Block])
+		r.crc = UpdateCRC(r.crcPrev, b[n-Block:])
+	}
+	r.stats.Blocks += int64(n / Block)
+	if r.trace != nil {
+		r.traceBlocks(base, b)
+	} else if r.c != nil {
+		t = startTimer(r.timing)
+		for i := 0; i < n; i += Block {
+			r.c.Decrypt(b[i:i+Block], b[i:i+Block])
+		}
+		stopTimer(&r.stats.CipherTime, t)
+	}
+	r.release(n)
+	if r.progress != nil {
+		r.reportProgress()
+	}
+	if r.n == r.i {
+		// only the held back blocks were read
+		return r.readBlocks(size)
 	}
 	return nil
 }
 
+// holdSize returns the number of decoded bytes that must be held back until EOF, see PKCS7 and CRCTrailer.
+func (r *Reader) holdSize() int {
+	n := 0
+	if r.PKCS7 {
+		n += Block
+	}
+	if r.CRCTrailer {
+		n += Block
+	}
+	return n
+}
+
+// release makes n newly decoded bytes available to the caller, except the ones that must be held back.
+func (r *Reader) release(n int) {
+	prev := r.n
+	vis := max(0, r.hn+n-r.holdSize())
+	r.n += vis
+	r.hn += n - vis
+	if r.crcMode != CRCCipher {
+		r.crc = updateCRCBlocks(r.crc, r.buf[prev:r.n])
+	}
+}
+
+// releaseHeld verifies and removes the CRC trailer and PKCS#7 padding from the held back blocks at the end of the stream.
+func (r *Reader) releaseHeld() error {
+	b := r.buf[r.n : r.n+r.hn]
+	if r.CRCTrailer {
+		if len(b) < Block {
+			return fmt.Errorf("%w: missing trailer", ErrChecksum)
+		}
+		t := b[len(b)-Block:]
+		b = b[:len(b)-Block]
+		crc := r.crcPrev
+		if r.crcMode != CRCCipher {
+			crc = updateCRCBlocks(r.crc, b)
+		}
+		if exp := binary.LittleEndian.Uint32(t); !r.crcLost && exp != crc {
+			return fmt.Errorf("%w: trailer %#08x, data %#08x", ErrChecksum, exp, crc)
+		}
+		r.crc = crc
+	}
+	n := len(b)
+	if r.PKCS7 {
+		if n < Block {
+			return ErrPadding
+		}
+		k := int(b[n-1])
+		if k < 1 || k > Block {
+			return ErrPadding
+		}
+		for _, v := range b[n-k:] {
+			if int(v) != k {
+				return ErrPadding
+			}
+		}
+		n -= k
+		r.stats.Padding += int64(k)
+	}
+	r.n += n
+	r.hn = 0
+	if r.n == r.i {
+		return io.EOF
+	}
+	return nil
+}
+
+// traceBlocks decodes blocks in place and reports each of them to the trace callback.
+func (r *Reader) traceBlocks(off int64, b []byte) {
+	var enc [Block]byte
+	for i := 0; i < len(b); i += Block {
+		blk := b[i : i+Block]
+		copy(enc[:], blk)
+		if r.c != nil {
+			r.c.Decrypt(blk, blk)
+		}
+		r.trace(off+int64(i), enc[:], blk)
+	}
+}
+
 func (r *Reader) read(p []byte) (int, error) {
-	if r.i < 0 || r.i >= Block {
-		if err := r.readNext(); err != nil {
+	if r.closed {
+		return 0, ErrClosed
+	}
+	if r.lim >= 0 && r.pos >= r.lim {
+		return 0, io.EOF
+	}
+	if r.i >= r.n {
+		if err := r.readBlocks(r.ReadAhead()); err != nil {
 			return 0, err
 		}
 	}
-	n := copy(p, r.buf[r.i:])
+	n := copy(p, r.buf[r.i:r.i+r.avail()])
 	r.i += n
+	r.pos += int64(n)
 	return n, nil
 }
 
 func (r *Reader) Read(p []byte) (int, error) {
 	total := 0
-	for len(p) > 0 {
-		n, err := r.read(p)
+	var err error
+	for total < len(p) {
+		var n int
+		n, err = r.read(p[total:])
 		total += n
 		if err != nil {
-			return total, err
+			break
+		}
+	}
+	if r.dump != nil && total > 0 {
+		hexDump(r.dump, r.pos-int64(total), p[:total])
+	}
+	if r.StrictEOF && total > 0 {
+		// the error will be returned by the next call
+		return total, nil
+	}
+	return total, wrapOffset(r.pos, err)
+}
+
+// readFull reads exactly len(p) bytes for fixed-size values. See StrictEOF.
+func (r *Reader) readFull(p []byte) error {
+	if err := r.checkLimit(int64(len(p))); err != nil {
+		return err
+	}
+	if !r.StrictEOF {
+		_, err := r.Read(p)
+		return err
+	}
+	_, err := io.ReadFull(r, p)
+	if err == io.ErrUnexpectedEOF {
+		err = wrapOffset(r.pos, err)
+	}
+	return err
+}
+
+// Peek returns the next n bytes without advancing the reader. The bytes stop being valid at the next read call.
+// If Peek returns fewer than n bytes, it also returns an error explaining why the read is short.
+// The number of bytes is limited by MaxAlloc.
+func (r *Reader) Peek(n int) ([]byte, error) {
+	if r.closed {
+		return nil, ErrClosed
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("%w: negative count %d", ErrInvalidSize, n)
+	}
+	if limit := r.MaxAlloc(); limit > 0 && n > limit {
+		return nil, fmt.Errorf("%w: %d > %d", ErrAllocLimit, n, limit)
+	}
+	var err error
+	if r.lim >= 0 && int64(n) > r.lim-r.pos {
+		n, err = int(r.lim-r.pos), io.EOF
+	}
+	for r.Buffered() < n {
+		if err := r.readNext(); err != nil {
+			return r.buf[r.i:r.n], err
+		}
+	}
+	return r.buf[r.i : r.i+n], err
+}
+
+// Discard skips the next n bytes, returning the number of bytes discarded.
+// If Discard skips fewer than n bytes, it also returns an error.
+func (r *Reader) Discard(n int) (int, error) {
+	if r.closed {
+		return 0, ErrClosed
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("%w: negative count %d", ErrInvalidSize, n)
+	}
+	total := 0
+	defer func() {
+		r.stats.Skipped += int64(total)
+	}()
+	for total < n {
+		if r.lim >= 0 && r.pos >= r.lim {
+			return total, io.EOF
 		}
-		p = p[n:]
+		if r.i >= r.n {
+			if err := r.readNext(); err != nil {
+				return total, err
+			}
+		}
+		m := min(n-total, r.avail())
+		r.i += m
+		r.pos += int64(m)
+		total += m
 	}
 	return total, nil
 }
 
+// WriteTo implements io.WriterTo. It decodes the data in large batches and writes it directly to w.
+// Batches are at least 32 KiB, or the read-ahead size if it is larger, see SetReadAhead.
+func (r *Reader) WriteTo(w io.Writer) (int64, error) {
+	if r.closed {
+		return 0, ErrClosed
+	}
+	var total int64
+	for {
+		if r.lim >= 0 && r.pos >= r.lim {
+			return total, nil
+		}
+		if r.i >= r.n {
+			if err := r.readBlocks(max(copyChunk, r.ReadAhead())); err == io.EOF {
+				return total, nil
+			} else if err != nil {
+				return total, wrapOffset(r.pos, err)
+			}
+		}
+		p := r.buf[r.i : r.i+r.avail()]
+		n, err := w.Write(p)
+		if r.dump != nil && n > 0 {
+			hexDump(r.dump, r.pos, p[:n])
+		}
+		r.i += n
+		r.pos += int64(n)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		} else if n < len(p) {
+			return total, io.ErrShortWrite
+		}
+	}
+}
+
 func (r *Reader) ReadU8() (byte, error) {
 	var b [1]byte
-	_, err := r.Read(b[:])
+	err := r.readFull(b[:])
 	return b[0], err
 }
 
 func (r *Reader) ReadU16() (uint16, error) {
 	var b [2]byte
-	_, err := r.Read(b[:])
+	err := r.readFull(b[:])
 	return binary.LittleEndian.Uint16(b[:]), err
 }
 
 func (r *Reader) ReadU32() (uint32, error) {
 	var b [4]byte
-	_, err := r.Read(b[:])
+	err := r.readFull(b[:])
 	return binary.LittleEndian.Uint32(b[:]), err
 }
 
 func (r *Reader) ReadU64() (uint64, error) {
 	var b [8]byte
-	_, err := r.Read(b[:])
+	err := r.readFull(b[:])
 	return binary.LittleEndian.Uint64(b[:]), err
 }
 
@@ -120,9 +716,115 @@ func (r *Reader) ReadI64() (int64, error) {
 	return int64(v), err
 }
 
+// ReadF32 reads IEEE 754 float32 value.
+func (r *Reader) ReadF32() (float32, error) {
+	v, err := r.ReadU32()
+	return math.Float32frombits(v), err
+}
+
+// ReadF64 reads IEEE 754 float64 value.
+func (r *Reader) ReadF64() (float64, error) {
+	v, err := r.ReadU64()
+	return math.Float64frombits(v), err
+}
+
+// SkipBlock is the same as SkipBlocks(1).
+func (r *Reader) SkipBlock() error {
+	return r.SkipBlocks(1)
+}
+
+// SkipBlocks discards the rest of the current block (if any) and skips n following blocks without decoding them.
+// It seeks the underlying reader, if possible. In that case, skipping past the end of the stream is not an error.
+func (r *Reader) SkipBlocks(n int) error {
+	if r.closed {
+		return ErrClosed
+	}
+	if n < 0 {
+		return fmt.Errorf("%w: negative block count %d", ErrInvalidSize, n)
+	}
+	start := r.pos
+	defer func() {
+		r.stats.Skipped += r.pos - start
+	}()
+	if r.lim >= 0 {
+		skip := int64(n) * Block
+		if r.Buffered() > 0 {
+			skip += int64((Block - r.i%Block) % Block)
+		}
+		if err := r.checkLimit(skip); err != nil {
+			return err
+		}
+	}
+	if r.Buffered() > 0 {
+		// discard the rest of the current block and use buffered blocks, if any
+		skip := (Block - r.i%Block) % Block
+		r.i += skip
+		r.pos += int64(skip)
+		m := min(n, r.Buffered()/Block)
+		r.i += m * Block
+		r.pos += int64(m) * Block
+		n -= m
+		if n == 0 {
+			return nil
+		}
+	}
+	if r.hn > 0 {
+		// skip held back blocks, keep the rest of them
+		m := min(n, r.hn/Block)
+		r.n += m * Block
+		r.i = r.n
+		r.hn -= m * Block
+		r.pos += int64(m) * Block
+		r.crcLost = true
+		n -= m
+		if n == 0 {
+			return nil
+		}
+	}
+	size := int64(n)*Block - int64(r.fill)
+	r.pos += int64(n) * Block
+	r.i, r.n = 0, 0
+	r.fill = 0
+	r.crcLost = true
+	if size <= 0 {
+		return nil
+	}
+	if r.s != nil {
+		cur, err := seek(r.s, 0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		if err = checkOffset(cur+size, r.MaxOffset()); err != nil {
+			return err
+		}
+		_, err = seek(r.s, size, io.SeekCurrent)
+		return err
+	}
+	m, err := io.CopyN(io.Discard, r.r, size)
+	if err == io.EOF && m != 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return wrapIO("read", err)
+}
+
 func (r *Reader) Align() error {
-	if n := r.Buffered(); n%Block != 0 {
-		if err := r.readNext(); err != nil {
+	// the end of buffered data is not always aligned (see PKCS7), so the position is used instead
+	if skip := (Block - int(r.pos%Block)) % Block; skip != 0 {
+		n := min(skip, r.Buffered())
+		if err := r.checkLimit(int64(n)); err != nil {
+			return err
+		}
+		var pad [Block]byte
+		copy(pad[:], r.buf[r.i:r.i+n])
+		r.i += n
+		r.pos += int64(n)
+		r.stats.Padding += int64(n)
+		if r.Buffered() != 0 {
+			return nil
+		}
+		if err := r.readNext(); err == io.EOF && r.VerifyPadding && !isZero(pad[:n]) {
+			return ErrPadding
+		} else if err != nil {
 			return err
 		}
 	}
@@ -144,23 +846,45 @@ func (r *Reader) ReadAligned(p []byte) (int, error) {
 	return n, nil
 }
 
+func (r *Reader) offset() (int64, error) {
+	cur, err := seek(r.s, 0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	return cur - int64(r.Buffered()) - int64(r.hn) - int64(r.fill), nil
+}
+
 func (r *Reader) Seek(off int64, whence int) (int64, error) {
+	if r.closed {
+		return 0, ErrClosed
+	}
 	if r.s == nil {
-		return 0, errors.New("reader cannot seek")
+		return 0, errSeekUnsupported
 	}
-	if whence == io.SeekCurrent {
-		off -= int64(r.Buffered())
+	off, err := seekTarget(r.s, off, whence, r.offset)
+	if err != nil {
+		return 0, err
 	}
-	cur, err := r.s.Seek(off, whence)
-	r.i = -1
+	if err = checkOffset(off, r.MaxOffset()); err != nil {
+		return 0, err
+	}
+	prev := r.pos
+	cur, err := seek(r.s, off, io.SeekStart)
+	r.i, r.n = 0, 0
+	r.fill, r.hn = 0, 0
+	r.crcLost = true
 	if err != nil {
 		return 0, err
 	}
+	r.pos = cur
+	if cur > prev {
+		r.stats.Skipped += cur - prev
+	}
 	rem := cur % Block
 	if rem == 0 {
 		return cur, nil
 	}
-	_, err = r.s.Seek(-rem, io.SeekCurrent)
+	_, err = seek(r.s, -rem, io.SeekCurrent)
 	if err != nil {
 		return 0, err
 	}
@@ -168,6 +892,58 @@ func (r *Reader) Seek(off int64, whence int) (int64, error) {
 	if err != nil {
 		return 0, err
 	}
-	r.i = int(rem)
+	r.i += int(rem)
 	return cur, nil
 }
+
+// ReadAt implements io.ReaderAt. It requires the underlying reader to implement io.ReaderAt.
+//
+// ReadAt doesn't affect the state of the Reader and is safe to call concurrently,
+// as long as the underlying reader allows it.
+func (r *Reader) ReadAt(p []byte, off int64) (int, error) {
+	if r.closed {
+		return 0, ErrClosed
+	}
+	if r.at == nil {
+		return 0, errReadAtUnsupported
+	}
+	if off >= 0 {
+		if err := checkOffset(off+int64(len(p)), r.MaxOffset()); err != nil {
+			return 0, err
+		}
+	}
+	return readAt(r.at, r.c, p, off)
+}
+
+// readAt decodes data at a given offset. It aligns reads to the block size internally.
+func readAt(ra io.ReaderAt, c *blowfish.Cipher, p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, ErrNegativeOffset
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	rem := int(off % Block)
+	buf := make([]byte, EncodedSize(int64(rem+len(p))))
+	n, err := ra.ReadAt(buf, off-int64(rem))
+	partial := n%Block != 0
+	n -= n % Block
+	if c != nil {
+		for i := 0; i < n; i += Block {
+			b := buf[i : i+Block]
+			c.Decrypt(b, b)
+		}
+	}
+	if n <= rem {
+		n = 0
+	} else {
+		n = copy(p, buf[rem:n])
+	}
+	if n == len(p) {
+		return n, nil
+	}
+	if err == nil || (err == io.EOF && partial) {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, wrapIO("read", err)
+}
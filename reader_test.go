@@ -1,6 +1,7 @@
 package crypt
 
 import (
+	"bytes"
 	"io"
 	"strings"
 	"testing"
@@ -54,3 +55,487 @@ func TestReaderNoKey(t *testing.T) {
 		require.Equal(t, decoded[i:], string(out))
 	}
 }
+
+func TestReaderSeekInvalid(t *testing.T) {
+	const (
+		key     = ThingBin
+		encoded = "\x2c\xc3\x70\x31\x5e\xda\x12\x3c\x49\x28\x27\x6d\x9a\x62\x8e\x94\x95\x36\x9f\xa2\x0d\xd0\x04\xee"
+		decoded = "ROLF\x01\x00\x00\x00\x03\x4d\x75\x64\x3e\x20\x03\x00\x08\x00\x00\x00\x00\x00\x00\x00"
+	)
+
+	r, err := NewReader(strings.NewReader(encoded), key)
+	require.NoError(t, err)
+	var buf [3]byte
+	_, err = io.ReadFull(r, buf[:])
+	require.NoError(t, err)
+
+	_, err = r.Seek(0, 3)
+	require.Error(t, err)
+	_, err = r.Seek(-1, io.SeekStart)
+	require.Error(t, err)
+	_, err = r.Seek(-4, io.SeekCurrent)
+	require.Error(t, err)
+	_, err = r.Seek(-int64(len(encoded))-1, io.SeekEnd)
+	require.Error(t, err)
+
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, decoded[3:], string(out))
+
+	off, err := r.Seek(-5, io.SeekEnd)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(decoded)-5), off)
+	out, err = io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, decoded[len(decoded)-5:], string(out))
+}
+
+func TestReaderVerifyPadding(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		data string
+		err  error
+	}{
+		{name: "zero", data: "1234\x00\x00\x00\x00", err: io.EOF},
+		{name: "non-zero", data: "123456\x00\x01", err: ErrPadding},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			r, err := NewReader(strings.NewReader(c.data), NoKey)
+			require.NoError(t, err)
+			r.VerifyPadding = true
+			var buf [4]byte
+			_, err = io.ReadFull(r, buf[:])
+			require.NoError(t, err)
+			require.Equal(t, "1234", string(buf[:]))
+			err = r.Align()
+			require.Equal(t, c.err, err)
+		})
+	}
+}
+
+func TestReaderReadAt(t *testing.T) {
+	const (
+		key     = ThingBin
+		encoded = "\x2c\xc3\x70\x31\x5e\xda\x12\x3c\x49\x28\x27\x6d\x9a\x62\x8e\x94\x95\x36\x9f\xa2\x0d\xd0\x04\xee"
+		decoded = "ROLF\x01\x00\x00\x00\x03\x4d\x75\x64\x3e\x20\x03\x00\x08\x00\x00\x00\x00\x00\x00\x00"
+	)
+
+	r, err := NewReader(strings.NewReader(encoded), key)
+	require.NoError(t, err)
+	for off := 0; off < len(decoded); off++ {
+		for n := 1; off+n <= len(decoded); n++ {
+			buf := make([]byte, n)
+			got, err := r.ReadAt(buf, int64(off))
+			require.NoError(t, err)
+			require.Equal(t, n, got)
+			require.Equal(t, decoded[off:off+n], string(buf))
+		}
+	}
+	buf := make([]byte, 4)
+	n, err := r.ReadAt(buf, int64(len(decoded)-2))
+	require.Equal(t, io.EOF, err)
+	require.Equal(t, 2, n)
+
+	r, err = NewReader(strings.NewReader(encoded[:len(encoded)-2]), key)
+	require.NoError(t, err)
+	n, err = r.ReadAt(buf, int64(len(decoded)-4))
+	require.Equal(t, io.ErrUnexpectedEOF, err)
+	require.Equal(t, 0, n)
+}
+
+func TestReaderMaxAlloc(t *testing.T) {
+	r, err := NewReader(strings.NewReader(""), NoKey)
+	require.NoError(t, err)
+	require.Equal(t, DefaultMaxAlloc, r.MaxAlloc())
+
+	_, err = r.alloc(DefaultMaxAlloc + 1)
+	require.ErrorIs(t, err, ErrAllocLimit)
+	_, err = r.alloc(-1)
+	require.ErrorIs(t, err, ErrAllocLimit)
+
+	r.SetMaxAlloc(16)
+	buf, err := r.alloc(16)
+	require.NoError(t, err)
+	require.Len(t, buf, 16)
+	_, err = r.alloc(17)
+	require.ErrorIs(t, err, ErrAllocLimit)
+
+	r.SetMaxAlloc(-1)
+	_, err = r.alloc(DefaultMaxAlloc + 1)
+	require.NoError(t, err)
+}
+
+func TestReaderDebugState(t *testing.T) {
+	r, err := NewReader(strings.NewReader("1234567890abcdef"), NoKey)
+	require.NoError(t, err)
+	var buf [3]byte
+	_, err = io.ReadFull(r, buf[:])
+	require.NoError(t, err)
+	require.Equal(t, "crypt.Reader{offset: 3, buffered: 13, readAhead: 4096, encrypted: false, verifyPadding: false, maxAlloc: 67108864, maxOffset: 1099511627776, seeker: true, readerAt: true}", r.DebugState())
+}
+
+func TestReaderClose(t *testing.T) {
+	r, err := NewReader(strings.NewReader("1234567890abcdef"), NoKey)
+	require.NoError(t, err)
+	var buf [3]byte
+	_, err = io.ReadFull(r, buf[:])
+	require.NoError(t, err)
+
+	require.NoError(t, r.Close())
+	require.NoError(t, r.Close())
+	_, err = r.Read(buf[:])
+	require.ErrorIs(t, err, ErrClosed)
+	_, err = r.Seek(0, io.SeekStart)
+	require.ErrorIs(t, err, ErrClosed)
+	_, err = r.ReadAt(buf[:], 0)
+	require.ErrorIs(t, err, ErrClosed)
+
+	r.Reset(strings.NewReader("1234567890abcdef"))
+	_, err = io.ReadFull(r, buf[:])
+	require.NoError(t, err)
+	require.Equal(t, "123", string(buf[:]))
+}
+
+func TestReaderSkipBlocks(t *testing.T) {
+	const (
+		key     = ThingBin
+		encoded = "\x2c\xc3\x70\x31\x5e\xda\x12\x3c\x49\x28\x27\x6d\x9a\x62\x8e\x94\x95\x36\x9f\xa2\x0d\xd0\x04\xee"
+		decoded = "ROLF\x01\x00\x00\x00\x03\x4d\x75\x64\x3e\x20\x03\x00\x08\x00\x00\x00\x00\x00\x00\x00"
+	)
+	for _, seekable := range []bool{true, false} {
+		open := func() *Reader {
+			var src io.Reader = strings.NewReader(encoded)
+			if !seekable {
+				src = io.MultiReader(src)
+			}
+			r, err := NewReader(src, key)
+			require.NoError(t, err)
+			return r
+		}
+		r := open()
+		require.NoError(t, r.SkipBlock())
+		out, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, decoded[Block:], string(out))
+
+		r = open()
+		var buf [3]byte
+		_, err = io.ReadFull(r, buf[:])
+		require.NoError(t, err)
+		require.NoError(t, r.SkipBlocks(1))
+		out, err = io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, decoded[2*Block:], string(out))
+
+		r = open()
+		require.NoError(t, r.SkipBlocks(0))
+		require.Error(t, r.SkipBlocks(-1))
+		if !seekable {
+			require.Equal(t, io.ErrUnexpectedEOF, r.SkipBlocks(4))
+		}
+	}
+}
+
+func TestReaderPeek(t *testing.T) {
+	const (
+		key     = ThingBin
+		encoded = "\x2c\xc3\x70\x31\x5e\xda\x12\x3c\x49\x28\x27\x6d\x9a\x62\x8e\x94\x95\x36\x9f\xa2\x0d\xd0\x04\xee"
+		decoded = "ROLF\x01\x00\x00\x00\x03\x4d\x75\x64\x3e\x20\x03\x00\x08\x00\x00\x00\x00\x00\x00\x00"
+	)
+	r, err := NewReader(strings.NewReader(encoded), key)
+	require.NoError(t, err)
+
+	p, err := r.Peek(3)
+	require.NoError(t, err)
+	require.Equal(t, decoded[:3], string(p))
+	p, err = r.Peek(12)
+	require.NoError(t, err)
+	require.Equal(t, decoded[:12], string(p))
+
+	var buf [5]byte
+	_, err = io.ReadFull(r, buf[:])
+	require.NoError(t, err)
+	require.Equal(t, decoded[:5], string(buf[:]))
+	off, err := r.Seek(0, io.SeekCurrent)
+	require.NoError(t, err)
+	require.EqualValues(t, 5, off)
+
+	p, err = r.Peek(len(decoded))
+	require.Equal(t, io.EOF, err)
+	require.Equal(t, decoded[5:], string(p))
+
+	n, err := r.Discard(6)
+	require.NoError(t, err)
+	require.Equal(t, 6, n)
+	require.NoError(t, r.SkipBlocks(0))
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, decoded[2*Block:], string(out))
+
+	r, err = NewReader(io.MultiReader(strings.NewReader(encoded)), key)
+	require.NoError(t, err)
+	_, err = r.Peek(2 * Block)
+	require.NoError(t, err)
+	require.NoError(t, r.SkipBlock())
+	n, err = r.Discard(len(decoded))
+	require.Equal(t, io.EOF, err)
+	require.Equal(t, 2*Block, n)
+
+	_, err = r.Peek(-1)
+	require.Error(t, err)
+	r.SetMaxAlloc(4)
+	_, err = r.Peek(5)
+	require.ErrorIs(t, err, ErrAllocLimit)
+}
+
+func TestReaderWriteTo(t *testing.T) {
+	data := make([]byte, 3*copyChunk+5*Block)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	enc := bytes.NewBuffer(nil)
+	w, err := NewWriter(enc, ThingBin)
+	require.NoError(t, err)
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	r, err := NewReader(bytes.NewReader(enc.Bytes()), ThingBin)
+	require.NoError(t, err)
+	var b [3]byte
+	_, err = io.ReadFull(r, b[:])
+	require.NoError(t, err)
+
+	out := bytes.NewBuffer(nil)
+	n, err := io.Copy(out, r)
+	require.NoError(t, err)
+	require.EqualValues(t, len(data)-3, n)
+	require.Equal(t, data[3:], out.Bytes())
+	require.EqualValues(t, len(data)/Block, r.Stats().Blocks)
+
+	// larger read-ahead is used for batches
+	r, err = NewReader(bytes.NewReader(enc.Bytes()), ThingBin, WithReadAhead(1<<20))
+	require.NoError(t, err)
+	out.Reset()
+	_, err = io.Copy(out, r)
+	require.NoError(t, err)
+	require.Equal(t, data, out.Bytes())
+	require.EqualValues(t, 2, r.Stats().Calls)
+
+	r, err = NewReader(bytes.NewReader(enc.Bytes()[:len(data)-3]), ThingBin)
+	require.NoError(t, err)
+	out.Reset()
+	_, err = r.WriteTo(out)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.Equal(t, data[:len(data)-Block], out.Bytes())
+}
+
+type countingReader struct {
+	r     io.Reader
+	calls int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	r.calls++
+	return r.r.Read(p)
+}
+
+func TestReaderReadAhead(t *testing.T) {
+	data := make([]byte, 64*Block)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	for _, c := range []struct {
+		ahead int
+		calls int
+	}{
+		{0, 2},
+		{-1, 65},
+		{16 * Block, 5},
+	} {
+		src := &countingReader{r: bytes.NewReader(data)}
+		r, err := NewReader(src, NoKey, WithReadAhead(c.ahead))
+		require.NoError(t, err)
+		var out bytes.Buffer
+		var b [3]byte
+		for {
+			n, err := r.Read(b[:])
+			out.Write(b[:n])
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+		}
+		require.Equal(t, data, out.Bytes())
+		require.Equal(t, c.calls, src.calls)
+	}
+
+	sr := bytes.NewReader(data)
+	r, err := NewReader(sr, NoKey)
+	require.NoError(t, err)
+	var b [Block + 3]byte
+	_, err = io.ReadFull(r, b[:])
+	require.NoError(t, err)
+	require.EqualValues(t, len(data), sr.Size()-int64(sr.Len()))
+	off, err := r.Seek(0, io.SeekCurrent)
+	require.NoError(t, err)
+	require.EqualValues(t, len(b), off)
+	require.NoError(t, r.Align())
+	require.NoError(t, r.SkipBlock())
+	v, err := r.ReadU8()
+	require.NoError(t, err)
+	require.Equal(t, data[3*Block], v)
+}
+
+func TestReaderStrictEOF(t *testing.T) {
+	data := []byte("1234567890abcdef")
+	for _, strict := range []bool{false, true} {
+		r, err := NewReader(bytes.NewReader(data), NoKey, WithStrictEOF(strict))
+		require.NoError(t, err)
+		_, err = r.ReadU64()
+		require.NoError(t, err)
+		_, err = r.ReadU32()
+		require.NoError(t, err)
+		_, err = r.ReadU64()
+		if strict {
+			require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		} else {
+			require.Equal(t, io.EOF, err)
+		}
+
+		r.Reset(bytes.NewReader(data[:12]))
+		buf := make([]byte, 16)
+		n, err := r.Read(buf)
+		require.Equal(t, Block, n)
+		if strict {
+			require.NoError(t, err)
+			n, err = r.Read(buf)
+			require.Equal(t, 0, n)
+		}
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestReaderOffset(t *testing.T) {
+	data := make([]byte, 4*Block)
+	r, err := NewReader(io.MultiReader(bytes.NewReader(data)), NoKey)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, r.Offset())
+	_, err = r.ReadU16()
+	require.NoError(t, err)
+	require.EqualValues(t, 2, r.Offset())
+	require.NoError(t, r.Align())
+	require.EqualValues(t, Block, r.Offset())
+	require.NoError(t, r.SkipBlock())
+	require.EqualValues(t, 2*Block, r.Offset())
+	_, err = r.Discard(3)
+	require.NoError(t, err)
+	require.EqualValues(t, 2*Block+3, r.Offset())
+
+	sr := bytes.NewReader(data)
+	r.Reset(sr)
+	_, err = r.Seek(3*Block+1, io.SeekStart)
+	require.NoError(t, err)
+	require.EqualValues(t, 3*Block+1, r.Offset())
+
+	// stream passed to Reset is already positioned, Seek makes the offset absolute
+	_, err = sr.Seek(Block, io.SeekStart)
+	require.NoError(t, err)
+	r.Reset(sr)
+	_, err = r.ReadU16()
+	require.NoError(t, err)
+	require.EqualValues(t, 2, r.Offset())
+	off, err := r.Seek(3, io.SeekCurrent)
+	require.NoError(t, err)
+	require.EqualValues(t, Block+5, off)
+	require.EqualValues(t, Block+5, r.Offset())
+}
+
+func TestReaderReadBytes(t *testing.T) {
+	data := make([]byte, 4*Block)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	for _, seekable := range []bool{true, false} {
+		var src io.Reader = bytes.NewReader(data)
+		if !seekable {
+			src = io.MultiReader(src)
+		}
+		r, err := NewReader(src, NoKey, WithMaxAlloc(2*Block))
+		require.NoError(t, err)
+		b, err := r.ReadBytes(3)
+		require.NoError(t, err)
+		require.Equal(t, data[:3], b)
+		b, err = r.ReadBytes(2 * Block)
+		require.NoError(t, err)
+		require.Equal(t, data[3:3+2*Block], b)
+		_, err = r.ReadBytes(2*Block + 1)
+		require.ErrorIs(t, err, ErrAllocLimit)
+		_, err = r.ReadBytes(-1)
+		require.ErrorIs(t, err, ErrAllocLimit)
+
+		_, err = r.ReadBytes(2 * Block)
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		if seekable {
+			// fails without consuming the data
+			require.EqualValues(t, 3+2*Block, r.Offset())
+			b, err = r.ReadBytes(2*Block - 3)
+			require.NoError(t, err)
+			require.Equal(t, data[3+2*Block:], b)
+		}
+	}
+}
+
+func TestReaderFixed(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf, MapKey)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteFixed([]byte("Estate"), 10))
+	require.ErrorIs(t, w.WriteFixed([]byte("Estate"), 5), ErrInvalidSize)
+	require.NoError(t, w.WriteFixed([]byte("MAP"), 3))
+	require.NoError(t, w.Close())
+	require.EqualValues(t, 2*Block, buf.Len())
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), MapKey)
+	require.NoError(t, err)
+	var name [10]byte
+	require.NoError(t, r.ReadFixed(name[:]))
+	require.Equal(t, "Estate\x00\x00\x00\x00", string(name[:]))
+	var short [3]byte
+	require.NoError(t, r.ReadFixed(short[:]))
+	require.Equal(t, "MAP", string(short[:]))
+	require.NoError(t, r.ReadFixed(short[:]))
+	require.ErrorIs(t, r.ReadFixed(short[:]), io.ErrUnexpectedEOF)
+	require.ErrorIs(t, r.ReadFixed(short[:]), io.ErrUnexpectedEOF)
+	require.NoError(t, r.ReadFixed(nil))
+}
+
+func TestReaderCRC(t *testing.T) {
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	enc := bytes.NewBuffer(nil)
+	w, err := NewWriter(enc, ThingBin)
+	require.NoError(t, err)
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	r, err := NewReader(bytes.NewReader(enc.Bytes()), ThingBin)
+	require.NoError(t, err)
+	require.Equal(t, ZeroCRC, r.CRC())
+	_, err = io.Copy(io.Discard, r)
+	require.NoError(t, err)
+	require.Equal(t, w.CRC(), r.CRC())
+	require.NoError(t, r.VerifyCRC(w.CRC()))
+	require.ErrorIs(t, r.VerifyCRC(w.CRC()+1), ErrChecksum)
+
+	_, err = r.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	require.ErrorIs(t, r.VerifyCRC(r.CRC()), ErrChecksum)
+	r.ResetCRC()
+	_, err = io.Copy(io.Discard, r)
+	require.NoError(t, err)
+	require.NoError(t, r.VerifyCRC(w.CRC()))
+}
@@ -0,0 +1,92 @@
+package crypt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ReadFile reads the named file and decodes it with a given key.
+// The file size must be a multiple of Block. Zero padding is preserved, since the original size is unknown.
+func ReadFile(path string, key Key, opts ...Option) ([]byte, error) {
+	c, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := st.Size()
+	if size%Block != 0 {
+		return nil, fmt.Errorf("%w: file size %d", ErrUnaligned, size)
+	}
+	r := newReader(f, c, opts)
+	r.progTotal = size
+	// the data can be shorter than the file, see WithPKCS7 and WithCRCTrailer
+	var buf bytes.Buffer
+	buf.Grow(int(size))
+	if _, err = buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteFile encodes the data with a given key and writes it to the named file, creating it if necessary.
+// The data is padded with zeros to a multiple of Block. The data slice is not modified.
+func WriteFile(path string, key Key, data []byte, opts ...Option) error {
+	c, err := NewCipher(key)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := newWriter(f, c, opts)
+	w.progTotal = EncodedSize(int64(len(data)))
+	if _, err = w.Write(data); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// crcFileChunk is the read-ahead size used by CRCFile.
+const crcFileChunk = 1 << 20
+
+// CRCFile decodes the named file with a given key and returns its checksum, same as Writer.CRC
+// reported when the file was written. The file size must be a multiple of Block.
+func CRCFile(path string, key Key) (uint32, error) {
+	c, err := NewCipher(key)
+	if err != nil {
+		return 0, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if size := st.Size(); size%Block != 0 {
+		return 0, fmt.Errorf("%w: file size %d", ErrUnaligned, size)
+	}
+	r := newReader(f, c, nil)
+	r.SetReadAhead(crcFileChunk)
+	if _, err = io.Copy(io.Discard, r); err != nil {
+		return 0, err
+	}
+	return r.CRC(), nil
+}
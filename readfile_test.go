@@ -0,0 +1,67 @@
+package crypt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.bin")
+	data := []byte("some file data")
+	require.NoError(t, WriteFile(path, ThingBin, data))
+	require.Equal(t, "some file data", string(data))
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, raw, 2*Block)
+
+	out, err := ReadFile(path, ThingBin)
+	require.NoError(t, err)
+	require.Equal(t, "some file data\x00\x00", string(out))
+
+	require.NoError(t, os.WriteFile(path, raw[:5], 0644))
+	_, err = ReadFile(path, ThingBin)
+	require.ErrorIs(t, err, ErrUnaligned)
+
+	err = WriteFile(path, Key(100), data)
+	require.ErrorIs(t, err, ErrInvalidKey)
+
+	require.NoError(t, WriteFile(path, ThingBin, data, WithPKCS7(true)))
+	out, err = ReadFile(path, ThingBin, WithPKCS7(true))
+	require.NoError(t, err)
+	require.Equal(t, "some file data", string(out))
+
+	require.NoError(t, WriteFile(path, ThingBin, data, WithCRCTrailer(true)))
+	out, err = ReadFile(path, ThingBin, WithCRCTrailer(true))
+	require.NoError(t, err)
+	require.Equal(t, "some file data\x00\x00", string(out))
+	out, err = ReadFile(path, ThingBin, WithPKCS7(true))
+	require.ErrorIs(t, err, ErrPadding)
+}
+
+func TestCRCFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.bin")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	w, err := NewWriter(f, ThingBin)
+	require.NoError(t, err)
+	data := make([]byte, 3<<20+5)
+	for i := range data {
+		data[i] = byte(i / 3)
+	}
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+
+	crc, err := CRCFile(path, ThingBin)
+	require.NoError(t, err)
+	require.Equal(t, w.CRC(), crc)
+
+	require.NoError(t, os.Truncate(path, 5))
+	_, err = CRCFile(path, ThingBin)
+	require.ErrorIs(t, err, ErrUnaligned)
+}
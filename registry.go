@@ -0,0 +1,105 @@
+package crypt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var registry = struct {
+	sync.RWMutex
+	names map[string]Key
+	pats  []keyPattern
+	exts  map[string]Key
+}{
+	names: map[string]Key{
+		"soundset.bin": SoundSetBin,
+		"thing.bin":    ThingBin,
+		"gamedata.bin": GameDataBin,
+		"modifier.bin": ModifierBin,
+		"monster.bin":  MonsterBin,
+	},
+	exts: map[string]Key{
+		".map": MapKey,
+		".plr": SaveKey,
+		".sav": SaveKey,
+	},
+}
+
+type keyPattern struct {
+	pattern string
+	key     Key
+}
+
+// RegisterExt registers a key for all files with a given extension (for example, ".map").
+// Extensions are case-insensitive. Registering the same extension again overrides the key.
+func RegisterExt(ext string, key Key) {
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	registry.exts[ext] = key
+}
+
+// RegisterName registers a key for files with a given name. The name may be a pattern, as accepted by filepath.Match.
+// Names are matched against the base name of the file and are case-insensitive.
+// Names take precedence over extensions, and names registered later take precedence over earlier ones.
+func RegisterName(pattern string, key Key) error {
+	pattern = strings.ToLower(pattern)
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return err
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	registry.pats = append(registry.pats, keyPattern{pattern: pattern, key: key})
+	return nil
+}
+
+// KeyForFile return crypto key for a given file. If the file is unknown, it returns false.
+//
+// Known files are maps (.map), player and save files (.plr, .sav) and game data files (thing.bin, gamedata.bin, etc).
+//
+// Additional files can be registered with RegisterName and RegisterExt.
+func KeyForFile(path string) (Key, bool) {
+	path = filepath.Base(path)
+	path = strings.ToLower(path)
+	registry.RLock()
+	defer registry.RUnlock()
+	for i := len(registry.pats) - 1; i >= 0; i-- {
+		p := registry.pats[i]
+		if ok, _ := filepath.Match(p.pattern, path); ok {
+			return p.key, true
+		}
+	}
+	if key, ok := registry.names[path]; ok {
+		return key, true
+	}
+	if key, ok := registry.exts[filepath.Ext(path)]; ok {
+		return key, true
+	}
+	return 0, false
+}
+
+// Open opens a file for reading and decodes it with a key returned by KeyForFile.
+// Closing the Reader closes the file as well, unless Reset is called.
+func Open(path string, opts ...Option) (*Reader, error) {
+	key, ok := KeyForFile(path)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFile, filepath.Base(path))
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewReader(f, key, opts...)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	r.closer = f
+	return r, nil
+}
@@ -0,0 +1,71 @@
+package crypt
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyForFile(t *testing.T) {
+	for _, c := range []struct {
+		path string
+		key  Key
+		ok   bool
+	}{
+		{"maps/Estate/Estate.map", MapKey, true},
+		{"Save/Player.PLR", SaveKey, true},
+		{"Save/SAVE0001/Player.sav", SaveKey, true},
+		{"soundset.bin", SoundSetBin, true},
+		{"modifier.bin", ModifierBin, true},
+		{"monster.bin", MonsterBin, true},
+		{"thing.bin", ThingBin, true},
+		{"GameData.bin", GameDataBin, true},
+		{"other.bin", 0, false},
+		{"file.txt", 0, false},
+	} {
+		key, ok := KeyForFile(c.path)
+		require.Equal(t, c.ok, ok, c.path)
+		require.Equal(t, c.key, key, c.path)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	RegisterExt("TST", ThingBin)
+	require.NoError(t, RegisterName("test_*.bin", ModifierBin))
+	require.Error(t, RegisterName("[", ModifierBin))
+	defer func() {
+		registry.Lock()
+		delete(registry.exts, ".tst")
+		registry.pats = registry.pats[:len(registry.pats)-1]
+		registry.Unlock()
+	}()
+
+	key, ok := KeyForFile("dir/file.tst")
+	require.True(t, ok)
+	require.Equal(t, ThingBin, key)
+	key, ok = KeyForFile("Test_1.bin")
+	require.True(t, ok)
+	require.Equal(t, ModifierBin, key)
+
+	const (
+		encoded = "\x2c\xc3\x70\x31\x5e\xda\x12\x3c\x49\x28\x27\x6d\x9a\x62\x8e\x94\x95\x36\x9f\xa2\x0d\xd0\x04\xee"
+		decoded = "ROLF\x01\x00\x00\x00\x03\x4d\x75\x64\x3e\x20\x03\x00\x08\x00\x00\x00\x00\x00\x00\x00"
+	)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.tst")
+	err := os.WriteFile(path, []byte(encoded), 0644)
+	require.NoError(t, err)
+
+	r, err := Open(path)
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, decoded, string(data))
+	require.NoError(t, r.Close())
+
+	_, err = Open(filepath.Join(dir, "data.unknown"))
+	require.ErrorIs(t, err, ErrUnknownFile)
+}
@@ -0,0 +1,95 @@
+package crypt
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+const saveChunk = 64 << 10
+
+// VerifySave checks the checksum embedded in the Nox player (.plr) or save (.sav) file.
+//
+// Both files are encrypted with SaveKey. The last block of the file holds the checksum (uint32)
+// of all preceding blocks, calculated the same way as Writer.CRC, followed by 4 reserved bytes.
+// ErrChecksum is returned if the checksum doesn't match.
+func VerifySave(r io.ReaderAt) error {
+	stored, actual, _, err := saveChecksum(r)
+	if err != nil {
+		return err
+	}
+	if stored != actual {
+		return fmt.Errorf("%w: stored %#08x, actual %#08x", ErrChecksum, stored, actual)
+	}
+	return nil
+}
+
+// FixSave recalculates the checksum of the Nox player or save file and rewrites it, if necessary.
+// See VerifySave for details.
+func FixSave(f *os.File) error {
+	stored, actual, off, err := saveChecksum(f)
+	if err != nil {
+		return err
+	}
+	if stored == actual {
+		return nil
+	}
+	var b [Block]byte
+	if _, err = f.ReadAt(b[:], off); err != nil {
+		return wrapIO("read", err)
+	}
+	if err = Decode(b[:], SaveKey); err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(b[:4], actual)
+	if err = Encode(b[:], SaveKey); err != nil {
+		return err
+	}
+	if _, err = f.WriteAt(b[:], off); err != nil {
+		return wrapIO("write", err)
+	}
+	return nil
+}
+
+// saveChecksum returns the stored and calculated checksum of the save file, as well as the offset of the trailer block.
+func saveChecksum(r io.ReaderAt) (stored, actual uint32, _ int64, _ error) {
+	c, err := NewCipher(SaveKey)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	var (
+		buf  = make([]byte, saveChunk)
+		last [Block]byte
+		off  int64
+		crc  = ZeroCRC
+		have bool
+	)
+	for {
+		n, err := r.ReadAt(buf, off)
+		if err != nil && err != io.EOF {
+			return 0, 0, 0, wrapIO("read", err)
+		}
+		if n%Block != 0 {
+			return 0, 0, 0, fmt.Errorf("%w: file size %d", ErrUnaligned, off+int64(n))
+		}
+		if err = DecodeWith(c, buf[:n]); err != nil {
+			return 0, 0, 0, err
+		}
+		for p := buf[:n]; len(p) > 0; p = p[Block:] {
+			if have {
+				crc = UpdateCRC(crc, last[:])
+			}
+			copy(last[:], p[:Block])
+			have = true
+		}
+		off += int64(n)
+		if n < len(buf) {
+			break
+		}
+	}
+	if !have {
+		return 0, 0, 0, fmt.Errorf("%w: no checksum block", io.ErrUnexpectedEOF)
+	}
+	return binary.LittleEndian.Uint32(last[:4]), crc, off - Block, nil
+}
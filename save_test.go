@@ -0,0 +1,43 @@
+package crypt
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveChecksum(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, SaveKey)
+	require.NoError(t, err)
+	_, err = w.Write(bytes.Repeat([]byte("player data "), saveChunk/8))
+	require.NoError(t, err)
+	require.NoError(t, w.Flush())
+	crc := w.CRC()
+	require.NoError(t, w.WriteU64(0))
+	require.NoError(t, w.Close())
+
+	data := buf.Bytes()
+	err = VerifySave(bytes.NewReader(data))
+	require.ErrorIs(t, err, ErrChecksum)
+
+	path := filepath.Join(t.TempDir(), "test.plr")
+	require.NoError(t, os.WriteFile(path, data, 0644))
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, FixSave(f))
+	require.NoError(t, VerifySave(f))
+
+	stored, actual, off, err := saveChecksum(f)
+	require.NoError(t, err)
+	require.Equal(t, crc, stored)
+	require.Equal(t, crc, actual)
+	require.EqualValues(t, len(data)-Block, off)
+
+	err = VerifySave(bytes.NewReader(data[:3]))
+	require.ErrorIs(t, err, ErrUnaligned)
+}
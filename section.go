@@ -0,0 +1,134 @@
+package crypt
+
+import (
+	"fmt"
+	"io"
+)
+
+// BeginSection starts a new section, which consists of a size block followed by the data.
+// The size block is reserved with WriteEmpty and is written by EndSection, once the size is known.
+// Sections can be nested. It requires the underlying writer to implement io.WriterAt.
+func (w *Writer) BeginSection() error {
+	off, err := w.WriteEmpty()
+	if err != nil {
+		return err
+	}
+	w.sections = append(w.sections, off)
+	return nil
+}
+
+// EndSection ends the section started by the last BeginSection call and writes its size as uint64.
+// The size is the number of bytes written after the size block, including nested sections.
+// The data is not flushed, thus the size is not necessarily a multiple of Block.
+func (w *Writer) EndSection() (int64, error) {
+	if len(w.sections) == 0 {
+		return 0, ErrNoSection
+	}
+	off := w.sections[len(w.sections)-1]
+	w.sections = w.sections[:len(w.sections)-1]
+	size := w.off - off - Block
+	if err := w.WriteU64At(uint64(size), off); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// SectionDepth returns the number of open sections.
+func (w *Writer) SectionDepth() int {
+	return len(w.sections)
+}
+
+// Section limits the Reader to the next n bytes, usually the size of the section decoded from its header.
+//
+// Until End is called, reads past the end of the section return io.EOF, and fixed-size reads (ReadU32, ReadBytes,
+// ReadFixed, etc.) that cross it return ErrSectionOverrun. Seek and ReadAt are not restricted.
+// Sections can be nested, but must be ended in reverse order.
+func (r *Reader) Section(n int64) (*Section, error) {
+	if r.closed {
+		return nil, ErrClosed
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("%w: negative section size %d", ErrInvalidSize, n)
+	}
+	if err := r.checkLimit(n); err != nil {
+		return nil, err
+	}
+	if err := r.CheckSize(n); err != nil {
+		return nil, err
+	}
+	s := &Section{r: r, start: r.pos, end: r.pos + n, prev: r.lim}
+	r.lim = s.end
+	return s, nil
+}
+
+// Section is a bounded part of the stream, see Reader.Section.
+type Section struct {
+	r     *Reader
+	start int64
+	end   int64
+	prev  int64 // limit of the parent section
+	done  bool
+}
+
+// Size returns the size of the section.
+func (s *Section) Size() int64 {
+	return s.end - s.start
+}
+
+// Remaining returns the number of bytes left in the section.
+func (s *Section) Remaining() int64 {
+	return max(0, s.end-s.r.pos)
+}
+
+// End skips the rest of the section and removes the limit from the Reader.
+// It seeks the underlying reader if possible, thus skipping large sections is cheap.
+// It is safe to call End multiple times.
+func (s *Section) End() error {
+	if s.done {
+		return nil
+	}
+	s.done = true
+	r := s.r
+	defer func() {
+		r.lim = s.prev
+	}()
+	rem := s.end - r.pos
+	if rem <= 0 {
+		return nil
+	}
+	if r.s != nil && rem > int64(r.Buffered()) {
+		pos := r.pos
+		if _, err := r.Seek(rem, io.SeekCurrent); err != nil {
+			return err
+		}
+		// keep the position relative to the beginning of the reader
+		r.pos = pos + rem
+		return nil
+	}
+	_, err := r.Discard(int(rem))
+	return err
+}
+
+// Limit makes the Reader stop after the next n bytes, usually the plaintext size declared in a header.
+// Reads past this point return io.EOF, thus the padding in the final block is never returned to the caller.
+// Fixed-size reads that cross the limit return ErrSectionOverrun, same as for Section.
+//
+// Unlike Section, the limit is not checked against the stream size and it stays in effect until Reset
+// or the next Limit call. Negative n removes the limit. Limit must not be called while a section is open.
+func (r *Reader) Limit(n int64) {
+	if n < 0 {
+		r.lim = -1
+		return
+	}
+	r.lim = r.pos + n
+}
+
+// NewLimitedReader creates a decoder that stops after n bytes of decoded data. See Reader.Limit.
+func NewLimitedReader(r io.Reader, key Key, n int64, opts ...Option) (*Reader, error) {
+	rd, err := NewReader(r, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	rd.Limit(n)
+	return rd, nil
+}
@@ -0,0 +1,141 @@
+package crypt
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriterSection(t *testing.T) {
+	buf := &bufferAt{}
+	w, err := NewWriter(buf, MapKey)
+	require.NoError(t, err)
+	require.NoError(t, w.BeginSection())
+	require.NoError(t, w.WriteU32(1))
+	require.NoError(t, w.BeginSection())
+	require.Equal(t, 2, w.SectionDepth())
+	require.NoError(t, w.WriteString0("nested"))
+	n, err := w.EndSection()
+	require.NoError(t, err)
+	require.EqualValues(t, 7, n)
+	require.NoError(t, w.WriteU8(2))
+	n, err = w.EndSection()
+	require.NoError(t, err)
+	require.EqualValues(t, Block+Block+8, n)
+	require.Equal(t, 0, w.SectionDepth())
+	_, err = w.EndSection()
+	require.ErrorIs(t, err, ErrNoSection)
+	require.NoError(t, w.Close())
+
+	r, err := NewReader(bytes.NewReader(buf.buf), MapKey)
+	require.NoError(t, err)
+	size, err := r.ReadU64()
+	require.NoError(t, err)
+	require.EqualValues(t, 3*Block, size)
+	_, err = r.ReadU32()
+	require.NoError(t, err)
+	require.NoError(t, r.Align())
+	size, err = r.ReadU64()
+	require.NoError(t, err)
+	require.EqualValues(t, 7, size)
+	s, err := r.ReadString0()
+	require.NoError(t, err)
+	require.Equal(t, "nested", s)
+
+	w, err = NewWriter(bytes.NewBuffer(nil), MapKey)
+	require.NoError(t, err)
+	require.NoError(t, w.BeginSection())
+	_, err = w.EndSection()
+	require.Error(t, err)
+}
+
+func TestReaderSection(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf, MapKey)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteU64(20))
+	require.NoError(t, w.WriteString8("Section"))
+	require.NoError(t, w.WriteU32(1))
+	require.NoError(t, w.WriteU64(2))
+	require.NoError(t, w.WriteU32(3))
+	require.NoError(t, w.WriteU32(4))
+	require.NoError(t, w.Close())
+
+	for _, seekable := range []bool{true, false} {
+		var src io.Reader = bytes.NewReader(buf.Bytes())
+		if !seekable {
+			src = io.MultiReader(src)
+		}
+		r, err := NewReader(src, MapKey)
+		require.NoError(t, err)
+		size, err := r.ReadU64()
+		require.NoError(t, err)
+		sec, err := r.Section(int64(size))
+		require.NoError(t, err)
+		require.EqualValues(t, 20, sec.Size())
+		name, err := r.ReadString8()
+		require.NoError(t, err)
+		require.Equal(t, "Section", name)
+
+		inner, err := r.Section(6)
+		require.NoError(t, err)
+		_, err = r.ReadU64()
+		require.ErrorIs(t, err, ErrSectionOverrun)
+		require.NoError(t, inner.End())
+		require.EqualValues(t, 6, sec.Remaining())
+
+		_, err = r.ReadU64()
+		require.ErrorIs(t, err, ErrSectionOverrun)
+		_, err = r.Section(7)
+		require.ErrorIs(t, err, ErrSectionOverrun)
+		rest, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Len(t, rest, 6)
+		_, err = r.ReadU8()
+		require.ErrorIs(t, err, ErrSectionOverrun)
+
+		require.NoError(t, sec.End())
+		require.NoError(t, sec.End())
+		v, err := r.ReadU32()
+		require.NoError(t, err)
+		require.EqualValues(t, 3, v)
+
+		r, err = NewReader(bytes.NewReader(buf.Bytes()), MapKey)
+		require.NoError(t, err)
+		_, err = r.ReadU64()
+		require.NoError(t, err)
+		sec, err = r.Section(20)
+		require.NoError(t, err)
+		require.NoError(t, sec.End())
+		v, err = r.ReadU32()
+		require.NoError(t, err)
+		require.EqualValues(t, 3, v)
+	}
+}
+
+func TestReaderLimit(t *testing.T) {
+	data := []byte("hello, world")
+	enc := make([]byte, EncodedSize(int64(len(data))))
+	copy(enc, data)
+	require.NoError(t, Encode(enc, MapKey))
+
+	r, err := NewLimitedReader(bytes.NewReader(enc), MapKey, int64(len(data)))
+	require.NoError(t, err)
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, data, got)
+	_, err = r.ReadU8()
+	require.ErrorIs(t, err, ErrSectionOverrun)
+
+	r, err = NewReader(bytes.NewReader(enc), MapKey)
+	require.NoError(t, err)
+	r.Limit(3)
+	_, err = r.ReadU32()
+	require.ErrorIs(t, err, ErrSectionOverrun)
+	r.Limit(-1)
+	got, err = io.ReadAll(r)
+	require.NoError(t, err)
+	require.Len(t, got, len(enc))
+}
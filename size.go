@@ -0,0 +1,59 @@
+package crypt
+
+import "io"
+
+// NewSizeWriter creates a Writer that performs all framing logic (padding, blocks reserved by WriteEmpty,
+// section sizes, etc.), but discards the encoded data. It can be used to calculate the encoded size
+// before writing the real file: after Flush or Close, Writer.Written returns the final size.
+//
+// The data is encoded with a given key, same as NewWriter does, thus CRC and CipherCRC match the ones
+// of a real Writer in all modes, including the checksum written by CRCTrailer.
+func NewSizeWriter(key Key, opts ...Option) (*Writer, error) {
+	c, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return newWriter(&sizeSink{}, c, opts), nil
+}
+
+// sizeSink discards all data, but tracks the position and the size, same as a file would.
+type sizeSink struct {
+	off  int64
+	size int64
+}
+
+func (s *sizeSink) Write(p []byte) (int, error) {
+	s.off += int64(len(p))
+	s.size = max(s.size, s.off)
+	return len(p), nil
+}
+
+func (s *sizeSink) WriteAt(p []byte, off int64) (int, error) {
+	s.size = max(s.size, off+int64(len(p)))
+	return len(p), nil
+}
+
+func (s *sizeSink) Seek(off int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		off += s.off
+	case io.SeekEnd:
+		off += s.size
+	default:
+		return 0, ErrInvalidWhence
+	}
+	if off < 0 {
+		return 0, ErrNegativeOffset
+	}
+	s.off = off
+	return off, nil
+}
+
+func (s *sizeSink) Truncate(size int64) error {
+	if size < 0 {
+		return ErrNegativeOffset
+	}
+	s.size = size
+	return nil
+}
@@ -0,0 +1,45 @@
+package crypt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSizeWriter(t *testing.T) {
+	write := func(w *Writer) {
+		require.NoError(t, w.WriteU32(0xFADEFACE))
+		_, err := w.WriteEmpty()
+		require.NoError(t, err)
+		require.NoError(t, w.BeginSection())
+		require.NoError(t, w.WriteString8("section"))
+		_, err = w.EndSection()
+		require.NoError(t, err)
+		_, err = w.WriteEmptyN(3)
+		require.NoError(t, err)
+		_, err = w.Write([]byte("tail"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+	}
+
+	for _, opts := range [][]Option{
+		nil,
+		{WithCRCMode(CRCBoth)},
+		{WithCRCMode(CRCCipher), WithCRCTrailer(true)},
+	} {
+		sw, err := NewSizeWriter(MapKey, opts...)
+		require.NoError(t, err)
+		write(sw)
+
+		buf := &bufferAt{}
+		w, err := NewWriter(buf, MapKey, opts...)
+		require.NoError(t, err)
+		write(w)
+
+		require.EqualValues(t, len(buf.buf), sw.Written())
+		require.Equal(t, w.CRC(), sw.CRC())
+		require.Equal(t, w.CipherCRC(), sw.CipherCRC())
+	}
+	_, err := NewSizeWriter(Key(100))
+	require.ErrorIs(t, err, ErrInvalidKey)
+}
@@ -0,0 +1,51 @@
+package crypt
+
+import "time"
+
+// Stats contains processing statistics of a Reader or a Writer.
+type Stats struct {
+	Blocks     int64         // number of blocks encoded or decoded
+	Padding    int64         // number of padding bytes added by Writer or skipped by Reader.Align
+	Flushes    int64         // number of Flush calls that wrote a partial block; Writer only
+	Calls      int64         // number of Read or Write calls on the underlying reader or writer
+	Skipped    int64         // number of bytes skipped by Seek, SkipBlocks and Discard; Reader only
+	CipherTime time.Duration // time spent in the cipher; only if timing is enabled
+	IOTime     time.Duration // time spent in the underlying reader or writer; only if timing is enabled
+}
+
+// startTimer returns current time if timing is enabled, or zero time otherwise.
+func startTimer(on bool) time.Time {
+	if !on {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// stopTimer adds time elapsed since t to d, if t is not zero.
+func stopTimer(d *time.Duration, t time.Time) {
+	if !t.IsZero() {
+		*d += time.Since(t)
+	}
+}
+
+// Stats returns processing statistics since the last Reset. ReadAt is not included.
+func (r *Reader) Stats() Stats {
+	return r.stats
+}
+
+// SetTiming enables measurement of time spent in the cipher and I/O, reported by Stats.
+// It is disabled by default, since it adds some overhead.
+func (r *Reader) SetTiming(on bool) {
+	r.timing = on
+}
+
+// Stats returns processing statistics since the last Reset. WriteAt and similar methods are not included.
+func (w *Writer) Stats() Stats {
+	return w.stats
+}
+
+// SetTiming enables measurement of time spent in the cipher and I/O, reported by Stats.
+// It is disabled by default, since it adds some overhead.
+func (w *Writer) SetTiming(on bool) {
+	w.timing = on
+}
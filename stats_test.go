@@ -0,0 +1,69 @@
+package crypt
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf, ThingBin, WithTiming(true))
+	require.NoError(t, err)
+	_, err = w.Write(make([]byte, 2*Block+3))
+	require.NoError(t, err)
+	require.NoError(t, w.Flush())
+	require.NoError(t, w.WriteZeros(4*Block))
+	require.NoError(t, w.Close())
+	st := w.Stats()
+	require.Equal(t, int64(7), st.Blocks)
+	require.Equal(t, int64(5), st.Padding)
+	require.Equal(t, int64(1), st.Flushes)
+
+	r, err := NewReader(buf, ThingBin)
+	require.NoError(t, err)
+	var b [3]byte
+	_, err = io.ReadFull(r, b[:])
+	require.NoError(t, err)
+	require.NoError(t, r.Align())
+	_, err = io.ReadAll(r)
+	require.NoError(t, err)
+	st = r.Stats()
+	require.Equal(t, int64(7), st.Blocks)
+	require.Equal(t, int64(5), st.Padding)
+	require.Zero(t, st.IOTime)
+
+	w.Reset(buf)
+	require.Equal(t, Stats{}, w.Stats())
+}
+
+func TestStatsCounters(t *testing.T) {
+	data := make([]byte, 16*Block)
+	enc, err := EncodePadded(ThingBin, data)
+	require.NoError(t, err)
+
+	r, err := NewReader(bytes.NewReader(enc), ThingBin, WithReadAhead(-1))
+	require.NoError(t, err)
+	_, err = r.ReadU32()
+	require.NoError(t, err)
+	require.NoError(t, r.SkipBlocks(2))
+	_, err = r.Discard(3)
+	require.NoError(t, err)
+	_, err = r.Seek(10*Block, io.SeekStart)
+	require.NoError(t, err)
+	_, err = r.Seek(Block, io.SeekStart)
+	require.NoError(t, err)
+	st := r.Stats()
+	require.Equal(t, int64(4+2*Block+3+(10*Block-(3*Block+3))), st.Skipped)
+	require.Equal(t, int64(2), st.Calls)
+
+	w, err := NewWriter(io.Discard, ThingBin)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteU32(1))
+	require.NoError(t, w.Flush())
+	_, err = w.Write(make([]byte, 2*bulkMin))
+	require.NoError(t, err)
+	require.Equal(t, int64(2), w.Stats().Calls)
+}
@@ -0,0 +1,231 @@
+package crypt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"unicode/utf16"
+)
+
+// ReadString0 reads a null-terminated string. The terminator is consumed, but not included in the result.
+// The length of the string is limited by MaxAlloc. If the stream ends before the terminator,
+// io.ErrUnexpectedEOF is returned.
+func (r *Reader) ReadString0() (string, error) {
+	if r.closed {
+		return "", ErrClosed
+	}
+	limit := r.MaxAlloc()
+	var out []byte
+	for {
+		if r.lim >= 0 && r.pos >= r.lim {
+			err := io.EOF
+			if len(out) != 0 {
+				err = fmt.Errorf("%w: unterminated string", ErrSectionOverrun)
+			}
+			return "", wrapOffset(r.pos, err)
+		}
+		if r.i >= r.n {
+			if err := r.readBlocks(r.ReadAhead()); err != nil {
+				if err == io.EOF && len(out) != 0 {
+					err = io.ErrUnexpectedEOF
+				}
+				return "", wrapOffset(r.pos, err)
+			}
+		}
+		p := r.buf[r.i : r.i+r.avail()]
+		n, end := len(p), false
+		if j := bytes.IndexByte(p, 0); j >= 0 {
+			n, end = j, true
+		}
+		if limit > 0 && len(out)+n > limit {
+			return "", fmt.Errorf("%w: string is longer than %d bytes", ErrAllocLimit, limit)
+		}
+		out = append(out, p[:n]...)
+		if end {
+			n++
+		}
+		if r.dump != nil {
+			hexDump(r.dump, r.pos, p[:n])
+		}
+		r.i += n
+		r.pos += int64(n)
+		if end {
+			return string(out), nil
+		}
+	}
+}
+
+// WriteString0 writes a null-terminated string. The string must not contain null bytes,
+// otherwise it will be truncated when reading it back.
+func (w *Writer) WriteString0(s string) error {
+	if _, err := w.WriteString(s); err != nil {
+		return err
+	}
+	return w.WriteByte(0)
+}
+
+// ReadString8 reads a string with 8 bit length prefix.
+func (r *Reader) ReadString8() (string, error) {
+	n, err := r.ReadU8()
+	if err != nil {
+		return "", err
+	}
+	return r.readString(int(n))
+}
+
+// ReadString16 reads a string with 16 bit length prefix.
+func (r *Reader) ReadString16() (string, error) {
+	n, err := r.ReadU16()
+	if err != nil {
+		return "", err
+	}
+	return r.readString(int(n))
+}
+
+// ReadString32 reads a string with 32 bit length prefix.
+// The length is limited by MaxAlloc to avoid huge allocations on corrupted files.
+func (r *Reader) ReadString32() (string, error) {
+	n, err := r.ReadU32()
+	if err != nil {
+		return "", err
+	}
+	if uint64(n) > math.MaxInt32 {
+		return "", fmt.Errorf("%w: string length %d", ErrAllocLimit, n)
+	}
+	return r.readString(int(n))
+}
+
+// readString reads a string of a given length, after the length prefix was read.
+func (r *Reader) readString(n int) (string, error) {
+	if err := r.checkLimit(int64(n)); err != nil {
+		return "", err
+	}
+	b, err := r.alloc(n)
+	if err != nil {
+		return "", err
+	}
+	if _, err = io.ReadFull(r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", wrapOffset(r.pos, err)
+	}
+	return string(b), nil
+}
+
+// WriteString8 writes a string with 8 bit length prefix.
+func (w *Writer) WriteString8(s string) error {
+	if len(s) > math.MaxUint8 {
+		return fmt.Errorf("%w: string length %d exceeds %d", ErrInvalidSize, len(s), math.MaxUint8)
+	}
+	if err := w.WriteU8(uint8(len(s))); err != nil {
+		return err
+	}
+	_, err := w.WriteString(s)
+	return err
+}
+
+// WriteString16 writes a string with 16 bit length prefix.
+func (w *Writer) WriteString16(s string) error {
+	if len(s) > math.MaxUint16 {
+		return fmt.Errorf("%w: string length %d exceeds %d", ErrInvalidSize, len(s), math.MaxUint16)
+	}
+	if err := w.WriteU16(uint16(len(s))); err != nil {
+		return err
+	}
+	_, err := w.WriteString(s)
+	return err
+}
+
+// WriteString32 writes a string with 32 bit length prefix.
+func (w *Writer) WriteString32(s string) error {
+	if uint64(len(s)) > math.MaxUint32 {
+		return fmt.Errorf("%w: string length %d exceeds %d", ErrInvalidSize, len(s), uint64(math.MaxUint32))
+	}
+	if err := w.WriteU32(uint32(len(s))); err != nil {
+		return err
+	}
+	_, err := w.WriteString(s)
+	return err
+}
+
+// ReadWString reads a fixed-size UTF-16LE string of n code units (2*n bytes).
+// The string ends at the first null character, the rest of the field is ignored.
+func (r *Reader) ReadWString(n int) (string, error) {
+	if n < 0 || n > math.MaxInt32/2 {
+		return "", fmt.Errorf("%w: string length %d", ErrAllocLimit, n)
+	}
+	if err := r.checkLimit(2 * int64(n)); err != nil {
+		return "", err
+	}
+	b, err := r.alloc(2 * n)
+	if err != nil {
+		return "", err
+	}
+	if _, err = io.ReadFull(r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", wrapOffset(r.pos, err)
+	}
+	u := make([]uint16, 0, n)
+	for i := 0; i < len(b); i += 2 {
+		c := binary.LittleEndian.Uint16(b[i:])
+		if c == 0 {
+			break
+		}
+		u = append(u, c)
+	}
+	return string(utf16.Decode(u)), nil
+}
+
+// ReadWString0 reads a null-terminated UTF-16LE string. The length of the string is limited by MaxAlloc.
+func (r *Reader) ReadWString0() (string, error) {
+	limit := r.MaxAlloc()
+	var u []uint16
+	for {
+		c, err := r.ReadU16()
+		if err == io.EOF && len(u) != 0 {
+			err = wrapOffset(r.pos, io.ErrUnexpectedEOF)
+		}
+		if err != nil {
+			return "", err
+		}
+		if c == 0 {
+			return string(utf16.Decode(u)), nil
+		}
+		if limit > 0 && 2*(len(u)+1) > limit {
+			return "", fmt.Errorf("%w: string is longer than %d bytes", ErrAllocLimit, limit)
+		}
+		u = append(u, c)
+	}
+}
+
+// WriteWString writes a string as a fixed-size UTF-16LE field of n code units (2*n bytes), padded with zeros.
+// Characters outside the Basic Multilingual Plane are encoded as surrogate pairs and take two code units.
+// If the string doesn't fit into the field, ErrInvalidSize is returned. The string which exactly fits the field
+// is written without the null terminator.
+func (w *Writer) WriteWString(s string, n int) error {
+	u := utf16.Encode([]rune(s))
+	if len(u) > n {
+		return fmt.Errorf("%w: string length %d exceeds %d", ErrInvalidSize, len(u), n)
+	}
+	return w.writeUTF16(u, n-len(u))
+}
+
+// WriteWString0 writes a null-terminated UTF-16LE string.
+func (w *Writer) WriteWString0(s string) error {
+	return w.writeUTF16(utf16.Encode([]rune(s)), 1)
+}
+
+// writeUTF16 writes UTF-16LE code units, followed by a given number of zero code units.
+func (w *Writer) writeUTF16(u []uint16, pad int) error {
+	b := make([]byte, 2*(len(u)+pad))
+	for i, c := range u {
+		binary.LittleEndian.PutUint16(b[2*i:], c)
+	}
+	_, err := w.Write(b)
+	return err
+}
@@ -0,0 +1,136 @@
+package crypt
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestString0(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf, ThingBin)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteString0("Estate"))
+	require.NoError(t, w.WriteString0(""))
+	require.NoError(t, w.WriteString0("some longer string, spanning multiple blocks"))
+	// padding works as a terminator
+	_, err = w.WriteString("no terminator")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), ThingBin)
+	require.NoError(t, err)
+	for _, exp := range []string{"Estate", "", "some longer string, spanning multiple blocks"} {
+		s, err := r.ReadString0()
+		require.NoError(t, err)
+		require.Equal(t, exp, s)
+	}
+	require.EqualValues(t, 7+1+45, r.Offset())
+	s, err := r.ReadString0()
+	require.NoError(t, err)
+	require.Equal(t, "no terminator", s)
+	require.Equal(t, io.EOF, r.Align())
+	_, err = r.ReadString0()
+	require.Equal(t, io.EOF, err)
+
+	r, err = NewReader(bytes.NewReader([]byte("12345678")), NoKey)
+	require.NoError(t, err)
+	_, err = r.ReadString0()
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	r, err = NewReader(bytes.NewReader(buf.Bytes()), ThingBin, WithMaxAlloc(10))
+	require.NoError(t, err)
+	_, err = r.ReadString0()
+	require.NoError(t, err)
+	_, err = r.ReadString0()
+	require.NoError(t, err)
+	_, err = r.ReadString0()
+	require.ErrorIs(t, err, ErrAllocLimit)
+}
+
+func TestStringPrefixed(t *testing.T) {
+	long := strings.Repeat("a", 300)
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf, ThingBin)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteString8("Estate"))
+	require.NoError(t, w.WriteString16(long))
+	require.NoError(t, w.WriteString32("Player"))
+	require.NoError(t, w.WriteString8(""))
+	require.ErrorIs(t, w.WriteString8(long), ErrInvalidSize)
+	require.NoError(t, w.Close())
+	require.EqualValues(t, 1+6+2+300+4+6+1, w.Written()-int64(w.Stats().Padding))
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), ThingBin)
+	require.NoError(t, err)
+	s, err := r.ReadString8()
+	require.NoError(t, err)
+	require.Equal(t, "Estate", s)
+	s, err = r.ReadString16()
+	require.NoError(t, err)
+	require.Equal(t, long, s)
+	s, err = r.ReadString32()
+	require.NoError(t, err)
+	require.Equal(t, "Player", s)
+	s, err = r.ReadString8()
+	require.NoError(t, err)
+	require.Equal(t, "", s)
+
+	r, err = NewReader(bytes.NewReader(buf.Bytes()), ThingBin, WithMaxAlloc(100))
+	require.NoError(t, err)
+	_, err = r.ReadString8()
+	require.NoError(t, err)
+	_, err = r.ReadString16()
+	require.ErrorIs(t, err, ErrAllocLimit)
+
+	r, err = NewReader(bytes.NewReader([]byte("\x0aabcdefg")), NoKey)
+	require.NoError(t, err)
+	_, err = r.ReadString8()
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestWString(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf, ThingBin)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteWString("Jack", 8))
+	require.NoError(t, w.WriteWString("Привет 😀", 9))
+	require.NoError(t, w.WriteWString0("Wizard"))
+	require.ErrorIs(t, w.WriteWString("Warrior", 4), ErrInvalidSize)
+	require.NoError(t, w.Close())
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), ThingBin)
+	require.NoError(t, err)
+	s, err := r.ReadWString(8)
+	require.NoError(t, err)
+	require.Equal(t, "Jack", s)
+	s, err = r.ReadWString(9)
+	require.NoError(t, err)
+	require.Equal(t, "Привет 😀", s)
+	require.EqualValues(t, 34, r.Offset())
+	s, err = r.ReadWString0()
+	require.NoError(t, err)
+	require.Equal(t, "Wizard", s)
+
+	r, err = NewReader(bytes.NewReader(buf.Bytes()), ThingBin, WithMaxAlloc(4))
+	require.NoError(t, err)
+	_, err = r.ReadWString(8)
+	require.ErrorIs(t, err, ErrAllocLimit)
+	_, err = r.ReadWString0()
+	require.ErrorIs(t, err, ErrAllocLimit)
+
+	// length prefix without the data
+	buf.Reset()
+	w.Reset(buf)
+	require.NoError(t, w.WriteU64(4))
+	require.NoError(t, w.Close())
+	r, err = NewReader(bytes.NewReader(buf.Bytes()), ThingBin)
+	require.NoError(t, err)
+	n, err := r.ReadU64()
+	require.NoError(t, err)
+	_, err = r.ReadWString(int(n))
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,347 @@
+package crypt
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// structTag is a parsed `nox` struct tag.
+type structTag struct {
+	skip  bool   // "-": field is ignored
+	align bool   // "align": field starts at the block boundary
+	pad   int    // "pad=N": skip N bytes before the field
+	kind  string // string encoding: string, wstring, string0, wstring0, string8, string16 or string32
+	size  int    // size of fixed string fields: "string=N" in bytes, "wstring=N" in code units
+}
+
+func parseStructTag(f reflect.StructField) (structTag, error) {
+	var t structTag
+	s, ok := f.Tag.Lookup("nox")
+	if !ok || s == "" {
+		return t, nil
+	}
+	if s == "-" {
+		t.skip = true
+		return t, nil
+	}
+	for _, opt := range strings.Split(s, ",") {
+		key, val, hasVal := strings.Cut(strings.TrimSpace(opt), "=")
+		n := 0
+		if hasVal {
+			var err error
+			n, err = strconv.Atoi(val)
+			if err != nil || n < 0 {
+				return t, fmt.Errorf("crypt: invalid tag value for field %s: %q", f.Name, opt)
+			}
+		}
+		switch key {
+		case "align":
+			t.align = true
+		case "pad":
+			t.pad = n
+		case "string", "wstring":
+			if !hasVal {
+				return t, fmt.Errorf("crypt: size is required for field %s: %q", f.Name, opt)
+			}
+			t.kind, t.size = key, n
+		case "string0", "wstring0", "string8", "string16", "string32":
+			t.kind = key
+		default:
+			return t, fmt.Errorf("crypt: unknown tag option for field %s: %q", f.Name, opt)
+		}
+	}
+	if t.kind != "" && f.Type.Kind() != reflect.String {
+		return t, fmt.Errorf("crypt: field %s with %s encoding must be a string, got %s", f.Name, t.kind, f.Type)
+	}
+	return t, nil
+}
+
+// ReadStruct decodes struct fields sequentially, similar to binary.Read with little-endian byte order.
+//
+// Supported field types are bool, fixed-size integers, floats, arrays and nested structs.
+// Blank (_) fields are skipped. Fields can be annotated with `nox` struct tags, with options separated by commas:
+//
+//   - "-" ignores the field;
+//   - "align" aligns the field to the block boundary, see Reader.Align and Writer.Flush;
+//   - "pad=N" skips N bytes of padding before the field;
+//   - "string=N" encodes the string as a fixed-size field of N bytes, see ReadFixed;
+//   - "wstring=N" encodes the string as a fixed-size UTF-16 field of N code units, see ReadWString;
+//   - "string0", "wstring0" encode null-terminated strings, see ReadString0 and ReadWString0;
+//   - "string8", "string16", "string32" encode length-prefixed strings, see ReadString8.
+func (r *Reader) ReadStruct(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("crypt: ReadStruct requires a pointer to struct, got %T", v)
+	}
+	return r.readStruct(rv.Elem())
+}
+
+func (r *Reader) readStruct(v reflect.Value) error {
+	rt := v.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		tag, err := parseStructTag(f)
+		if err != nil {
+			return err
+		} else if tag.skip {
+			continue
+		}
+		if tag.align {
+			if err = r.Align(); err != nil {
+				return fmt.Errorf("crypt: read field %s: %w", f.Name, err)
+			}
+		}
+		if tag.pad != 0 {
+			if _, err = r.Discard(tag.pad); err != nil {
+				return fmt.Errorf("crypt: read field %s: %w", f.Name, err)
+			}
+		}
+		if f.Name == "_" {
+			size, err := fixedSize(f.Type)
+			if err != nil {
+				return fmt.Errorf("crypt: field %s: %w", f.Name, err)
+			}
+			if _, err = r.Discard(size); err != nil {
+				return fmt.Errorf("crypt: read field %s: %w", f.Name, err)
+			}
+			continue
+		} else if !f.IsExported() {
+			return fmt.Errorf("crypt: field %s is not exported", f.Name)
+		}
+		if err = r.readValue(v.Field(i), tag); err != nil {
+			return fmt.Errorf("crypt: read field %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+func (r *Reader) readValue(v reflect.Value, tag structTag) error {
+	if tag.kind != "" {
+		var (
+			s   string
+			err error
+		)
+		switch tag.kind {
+		case "string":
+			var b []byte
+			if b, err = r.alloc(tag.size); err == nil {
+				if err = r.ReadFixed(b); err == nil {
+					if i := bytes.IndexByte(b, 0); i >= 0 {
+						b = b[:i]
+					}
+					s = string(b)
+				}
+			}
+		case "wstring":
+			s, err = r.ReadWString(tag.size)
+		case "string0":
+			s, err = r.ReadString0()
+		case "wstring0":
+			s, err = r.ReadWString0()
+		case "string8":
+			s, err = r.ReadString8()
+		case "string16":
+			s, err = r.ReadString16()
+		case "string32":
+			s, err = r.ReadString32()
+		}
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := r.ReadU8()
+		v.SetBool(b != 0)
+		return err
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		u, err := r.readUint(int(v.Type().Size()))
+		v.SetInt(int64(u) << (64 - 8*v.Type().Size()) >> (64 - 8*v.Type().Size()))
+		return err
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := r.readUint(int(v.Type().Size()))
+		v.SetUint(u)
+		return err
+	case reflect.Float32:
+		f, err := r.ReadF32()
+		v.SetFloat(float64(f))
+		return err
+	case reflect.Float64:
+		f, err := r.ReadF64()
+		v.SetFloat(f)
+		return err
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return r.ReadFixed(v.Slice(0, v.Len()).Bytes())
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := r.readValue(v.Index(i), structTag{}); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+		return r.readStruct(v)
+	}
+	return fmt.Errorf("crypt: unsupported type %s", v.Type())
+}
+
+func (r *Reader) readUint(size int) (uint64, error) {
+	switch size {
+	case 1:
+		v, err := r.ReadU8()
+		return uint64(v), err
+	case 2:
+		v, err := r.ReadU16()
+		return uint64(v), err
+	case 4:
+		v, err := r.ReadU32()
+		return uint64(v), err
+	default:
+		return r.ReadU64()
+	}
+}
+
+// fixedSize returns the encoded size of blank fields.
+func fixedSize(t reflect.Type) (int, error) {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return int(t.Size()), nil
+	case reflect.Array:
+		n, err := fixedSize(t.Elem())
+		return n * t.Len(), err
+	}
+	return 0, fmt.Errorf("crypt: unsupported type %s", t)
+}
+
+// WriteStruct encodes struct fields sequentially, similar to binary.Write with little-endian byte order.
+// See Reader.ReadStruct for supported types and tags. Padding and blank fields are written as zeros.
+func (w *Writer) WriteStruct(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("crypt: WriteStruct requires a struct, got %T", v)
+	}
+	return w.writeStruct(rv)
+}
+
+func (w *Writer) writeStruct(v reflect.Value) error {
+	rt := v.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		tag, err := parseStructTag(f)
+		if err != nil {
+			return err
+		} else if tag.skip {
+			continue
+		}
+		if tag.align {
+			if err = w.Flush(); err != nil {
+				return fmt.Errorf("crypt: write field %s: %w", f.Name, err)
+			}
+		}
+		if tag.pad != 0 {
+			if err = w.WriteZeros(int64(tag.pad)); err != nil {
+				return fmt.Errorf("crypt: write field %s: %w", f.Name, err)
+			}
+		}
+		if f.Name == "_" {
+			size, err := fixedSize(f.Type)
+			if err != nil {
+				return fmt.Errorf("crypt: field %s: %w", f.Name, err)
+			}
+			if err = w.WriteZeros(int64(size)); err != nil {
+				return fmt.Errorf("crypt: write field %s: %w", f.Name, err)
+			}
+			continue
+		} else if !f.IsExported() {
+			return fmt.Errorf("crypt: field %s is not exported", f.Name)
+		}
+		if err = w.writeValue(v.Field(i), tag); err != nil {
+			return fmt.Errorf("crypt: write field %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+func (w *Writer) writeValue(v reflect.Value, tag structTag) error {
+	if tag.kind != "" {
+		s := v.String()
+		switch tag.kind {
+		case "string":
+			if len(s) > tag.size {
+				return fmt.Errorf("%w: string length %d exceeds %d", ErrInvalidSize, len(s), tag.size)
+			}
+			if _, err := w.WriteString(s); err != nil {
+				return err
+			}
+			return w.WriteZeros(int64(tag.size - len(s)))
+		case "wstring":
+			return w.WriteWString(s, tag.size)
+		case "string0":
+			return w.WriteString0(s)
+		case "wstring0":
+			return w.WriteWString0(s)
+		case "string8":
+			return w.WriteString8(s)
+		case "string16":
+			return w.WriteString16(s)
+		case "string32":
+			return w.WriteString32(s)
+		}
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		var b byte
+		if v.Bool() {
+			b = 1
+		}
+		return w.WriteU8(b)
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return w.writeUint(uint64(v.Int()), int(v.Type().Size()))
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return w.writeUint(v.Uint(), int(v.Type().Size()))
+	case reflect.Float32:
+		return w.WriteF32(float32(v.Float()))
+	case reflect.Float64:
+		return w.WriteF64(v.Float())
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			_, err := w.Write(b)
+			return err
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := w.writeValue(v.Index(i), structTag{}); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+		return w.writeStruct(v)
+	}
+	return fmt.Errorf("crypt: unsupported type %s", v.Type())
+}
+
+func (w *Writer) writeUint(v uint64, size int) error {
+	switch size {
+	case 1:
+		return w.WriteU8(uint8(v))
+	case 2:
+		return w.WriteU16(uint16(v))
+	case 4:
+		return w.WriteU32(uint32(v))
+	default:
+		return w.WriteU64(v)
+	}
+}
@@ -0,0 +1,73 @@
+package crypt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testPoint struct {
+	X, Y float32
+}
+
+type testStruct struct {
+	Magic   uint32
+	Flag    bool
+	Level   int8
+	_       [2]byte
+	Pos     testPoint
+	Name    string `nox:"string=10"`
+	Player  string `nox:"wstring=8"`
+	Desc    string `nox:"string16"`
+	Author  string `nox:"align,string0"`
+	Title   string `nox:"wstring0"`
+	Delta   int16  `nox:"pad=3"`
+	IDs     [3]uint16
+	Hash    [4]byte
+	Ignored int `nox:"-"`
+}
+
+func TestStruct(t *testing.T) {
+	v := testStruct{
+		Magic:   mapMagic,
+		Flag:    true,
+		Level:   -3,
+		Pos:     testPoint{X: 1.5, Y: -2},
+		Name:    "Estate",
+		Player:  "Jack",
+		Desc:    "description",
+		Author:  "author",
+		Title:   "title",
+		Delta:   -300,
+		IDs:     [3]uint16{1, 2, 3},
+		Hash:    [4]byte{4, 5, 6, 7},
+		Ignored: 1,
+	}
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf, MapKey)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteStruct(v))
+	require.NoError(t, w.Close())
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), MapKey)
+	require.NoError(t, err)
+	var got testStruct
+	require.NoError(t, r.ReadStruct(&got))
+	v.Ignored = 0
+	require.Equal(t, v, got)
+	require.EqualValues(t, 56+7+12+3+2+6+4, r.Offset())
+
+	require.Error(t, r.ReadStruct(got))
+	require.Error(t, w.WriteStruct(1))
+
+	type badTag struct {
+		A int32 `nox:"string=4"`
+	}
+	require.Error(t, w.WriteStruct(badTag{}))
+	type badType struct {
+		A int
+	}
+	w.Reset(bytes.NewBuffer(nil))
+	require.Error(t, w.WriteStruct(badType{}))
+}
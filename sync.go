@@ -0,0 +1,115 @@
+package crypt
+
+import "sync"
+
+// NewSyncReader wraps Reader and makes it safe for concurrent use.
+func NewSyncReader(r *Reader) *SyncReader {
+	return &SyncReader{r: r}
+}
+
+// SyncReader is a Reader that is safe for concurrent use.
+// All methods are serialized with a mutex, except for ReadAt, which is stateless:
+// it only takes a shared lock, thus multiple ReadAt calls can run concurrently.
+type SyncReader struct {
+	mu sync.RWMutex
+	r  *Reader
+}
+
+// Do calls fn with the underlying Reader while holding the lock.
+// It allows to perform multiple operations atomically.
+func (r *SyncReader) Do(fn func(r *Reader) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return fn(r.r)
+}
+
+// Read implements io.Reader.
+func (r *SyncReader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.r.Read(p)
+}
+
+// ReadAt implements io.ReaderAt. See Reader.ReadAt.
+func (r *SyncReader) ReadAt(p []byte, off int64) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.r.ReadAt(p, off)
+}
+
+// Seek implements io.Seeker.
+func (r *SyncReader) Seek(off int64, whence int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.r.Seek(off, whence)
+}
+
+// NewSyncWriter wraps Writer and makes it safe for concurrent use.
+func NewSyncWriter(w *Writer) *SyncWriter {
+	return &SyncWriter{w: w}
+}
+
+// SyncWriter is a Writer that is safe for concurrent use.
+// All methods are serialized with a mutex, except for WriteBlockAt, which is stateless:
+// it only takes a shared lock, thus multiple WriteBlockAt calls can run concurrently.
+type SyncWriter struct {
+	mu sync.RWMutex
+	w  *Writer
+}
+
+// Do calls fn with the underlying Writer while holding the lock.
+// It allows to perform multiple operations atomically.
+func (w *SyncWriter) Do(fn func(w *Writer) error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return fn(w.w)
+}
+
+// Write implements io.Writer.
+func (w *SyncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+// WriteEmpty writes an empty block. See Writer.WriteEmpty.
+func (w *SyncWriter) WriteEmpty() (int64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.WriteEmpty()
+}
+
+// WriteBlockAt writes a block at a given offset. See Writer.WriteBlockAt.
+func (w *SyncWriter) WriteBlockAt(buf [Block]byte, off int64) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.w.WriteBlockAt(buf, off)
+}
+
+// Flush buffered data. See Writer.Flush.
+func (w *SyncWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Flush()
+}
+
+// Close flushes the data. See Writer.Close.
+func (w *SyncWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Close()
+}
+
+// Written returns a number of bytes written. See Writer.Written.
+func (w *SyncWriter) Written() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Written()
+}
+
+// CRC returns current CRC checksum. See Writer.CRC.
+func (w *SyncWriter) CRC() uint32 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.CRC()
+}
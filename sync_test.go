@@ -0,0 +1,98 @@
+package crypt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncReaderWriter(t *testing.T) {
+	const (
+		key     = ThingBin
+		workers = 4
+		records = 64
+	)
+	f, err := os.CreateTemp("", "crypt-sync-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		f.Close()
+		os.Remove(f.Name())
+	}()
+
+	cw, err := NewWriter(f, key)
+	require.NoError(t, err)
+	// the first block is written before any concurrent access
+	off, err := cw.WriteEmpty()
+	require.NoError(t, err)
+	_, err = cw.Write([]byte("ROLF\x01\x00\x00\x00"))
+	require.NoError(t, err)
+	w := NewSyncWriter(cw)
+
+	cr, err := NewReader(f, key)
+	require.NoError(t, err)
+	r := NewSyncReader(cr)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var b [Block]byte
+			for j := 0; j < records; j++ {
+				binary.LittleEndian.PutUint32(b[:], uint32(i))
+				_, err := w.Write(b[:])
+				require.NoError(t, err)
+				err = w.WriteBlockAt(b, off)
+				require.NoError(t, err)
+				_, err = r.ReadAt(b[:], Block)
+				require.NoError(t, err)
+				require.Equal(t, "ROLF\x01\x00\x00\x00", string(b[:]))
+			}
+		}(i)
+	}
+	wg.Wait()
+	require.NoError(t, w.Close())
+	require.Equal(t, int64((2+workers*records)*Block), w.Written())
+
+	err = r.Do(func(r *Reader) error {
+		_, err := r.Seek(0, io.SeekStart)
+		return err
+	})
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Len(t, data, (2+workers*records)*Block)
+	require.Less(t, binary.LittleEndian.Uint32(data), uint32(workers))
+}
+
+func TestSyncReaderReadAtReset(t *testing.T) {
+	data, err := EncodePadded(ThingBin, []byte("some data"))
+	require.NoError(t, err)
+	cr, err := NewReader(bytes.NewReader(data), ThingBin)
+	require.NoError(t, err)
+	r := NewSyncReader(cr)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		var b [Block]byte
+		for i := 0; i < 100; i++ {
+			_, _ = r.ReadAt(b[:], 0)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		_ = r.Do(func(r *Reader) error {
+			r.Reset(bytes.NewReader(data))
+			return nil
+		})
+	}
+	wg.Wait()
+}
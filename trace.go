@@ -0,0 +1,32 @@
+package crypt
+
+// BlockTraceFunc is called for each block processed by Reader or Writer.
+// It receives the offset of the block in the stream, its encoded and decoded contents.
+// Both slices are only valid during the call and must not be modified.
+type BlockTraceFunc func(off int64, enc, dec []byte)
+
+// WithBlockTrace sets a block trace callback for Reader and Writer. See Reader.SetBlockTrace and Writer.SetBlockTrace.
+func WithBlockTrace(fnc BlockTraceFunc) Option {
+	return func(o *options) {
+		if o.r != nil {
+			o.r.SetBlockTrace(fnc)
+		}
+		if o.w != nil {
+			o.w.SetBlockTrace(fnc)
+		}
+	}
+}
+
+// SetBlockTrace sets a callback that is called for each decoded block. ReadAt is not traced.
+// It is intended for debugging and slows down decoding significantly. Nil disables the trace.
+func (r *Reader) SetBlockTrace(fnc BlockTraceFunc) {
+	r.trace = fnc
+}
+
+// SetBlockTrace sets a callback that is called for each encoded block, including padding blocks
+// written by WriteZeros. Blocks written by WriteAt and similar methods, as well as empty blocks reserved
+// by WriteEmpty, are not traced.
+// It is intended for debugging and slows down encoding significantly. Nil disables the trace.
+func (w *Writer) SetBlockTrace(fnc BlockTraceFunc) {
+	w.trace = fnc
+}
@@ -0,0 +1,52 @@
+package crypt
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type traceEntry struct {
+	off      int64
+	enc, dec string
+}
+
+func TestBlockTrace(t *testing.T) {
+	var wlog []traceEntry
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, MapKey, WithBlockTrace(func(off int64, enc, dec []byte) {
+		wlog = append(wlog, traceEntry{off, string(enc), string(dec)})
+	}))
+	require.NoError(t, err)
+	require.NoError(t, w.WriteU32(1))
+	require.NoError(t, w.Flush())
+	_, err = w.Write(bytes.Repeat([]byte("bulkdata"), bulkMin/Block))
+	require.NoError(t, err)
+	require.NoError(t, w.WriteZeros(2*Block))
+	require.NoError(t, w.Close())
+
+	data := buf.Bytes()
+	require.Len(t, wlog, len(data)/Block)
+	plain := append([]byte{}, data...)
+	require.NoError(t, Decode(plain, MapKey))
+	for i, e := range wlog {
+		off := i * Block
+		require.Equal(t, int64(off), e.off)
+		require.Equal(t, string(data[off:off+Block]), e.enc)
+		require.Equal(t, string(plain[off:off+Block]), e.dec)
+	}
+
+	var rlog []traceEntry
+	r, err := NewReader(bytes.NewReader(data), MapKey, WithReadAhead(3*Block), WithBlockTrace(func(off int64, enc, dec []byte) {
+		rlog = append(rlog, traceEntry{off, string(enc), string(dec)})
+	}))
+	require.NoError(t, err)
+	_, err = r.ReadU16()
+	require.NoError(t, err)
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, plain[2:], out)
+	require.Equal(t, wlog, rlog)
+}
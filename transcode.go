@@ -0,0 +1,114 @@
+package crypt
+
+import (
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/blowfish"
+)
+
+// NewTranscoder creates a Transcoder that re-encrypts data from one key to another.
+// Either key can be NoKey, which means the data on that side is not encrypted.
+func NewTranscoder(from, to Key) (*Transcoder, error) {
+	dec, err := NewCipher(from)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := NewCipher(to)
+	if err != nil {
+		return nil, err
+	}
+	return &Transcoder{dec: dec, enc: enc}, nil
+}
+
+// Transcoder reads blocks encrypted with one key and writes them encrypted with another key.
+// The data is processed in chunks, without buffering the whole stream.
+//
+// Transcoder is not safe for concurrent use.
+type Transcoder struct {
+	// ComputeCRC enables calculation of the CRC checksum of the decoded data. See CRC.
+	ComputeCRC bool
+	// Progress is called after each processed chunk with the number of bytes written so far,
+	// and the size of the source, or -1 if it's unknown. See WithProgress.
+	Progress func(done, total int64)
+
+	dec *blowfish.Cipher
+	enc *blowfish.Cipher
+	buf []byte
+	crc uint32
+}
+
+// CRC returns the CRC checksum of the data decoded by the last Transcode call, if ComputeCRC is set.
+// The checksum is the same as reported by Writer.CRC for the same data.
+func (t *Transcoder) CRC() uint32 {
+	return t.crc
+}
+
+// Transcode reads all data from src, re-encrypts it and writes to dst. It returns the number of bytes written.
+// The size of the source data must be a multiple of Block, otherwise ErrUnaligned is returned after writing all complete blocks.
+func (t *Transcoder) Transcode(dst io.Writer, src io.Reader) (int64, error) {
+	if t.buf == nil {
+		t.buf = make([]byte, copyChunk)
+	}
+	t.crc = ZeroCRC
+	size := int64(-1)
+	if t.Progress != nil {
+		if s, ok := src.(io.Seeker); ok {
+			if n, err := seekRemaining(s); err == nil {
+				size = n
+			}
+		}
+	}
+	var total int64
+	for {
+		n, err := io.ReadFull(src, t.buf)
+		if err == io.EOF {
+			return total, nil
+		} else if err != nil && err != io.ErrUnexpectedEOF {
+			return total, wrapIO("read", err)
+		}
+		rem := n % Block
+		b := t.buf[:n-rem]
+		for i := 0; i < len(b); i += Block {
+			blk := b[i : i+Block]
+			if t.dec != nil {
+				t.dec.Decrypt(blk, blk)
+			}
+			if t.ComputeCRC {
+				t.crc = UpdateCRC(t.crc, blk)
+			}
+			if t.enc != nil {
+				t.enc.Encrypt(blk, blk)
+			}
+		}
+		m, werr := dst.Write(b)
+		total += int64(m)
+		if t.Progress != nil && m > 0 {
+			t.Progress(total, size)
+		}
+		if werr != nil {
+			return total, wrapIO("write", werr)
+		}
+		if rem != 0 {
+			return total, fmt.Errorf("%w: %d trailing bytes", ErrUnaligned, rem)
+		}
+		if err != nil {
+			return total, nil
+		}
+	}
+}
+
+// Recrypt reads the data encrypted with one key from src and writes it to dst encrypted with another key.
+// It returns the number of bytes processed. See Transcoder for details.
+//
+// Only WithProgress option is supported.
+func Recrypt(dst io.Writer, src io.Reader, from, to Key, opts ...Option) (int64, error) {
+	t, err := NewTranscoder(from, to)
+	if err != nil {
+		return 0, err
+	}
+	var o options
+	o.apply(opts)
+	t.Progress = o.progress
+	return t.Transcode(dst, src)
+}
@@ -0,0 +1,53 @@
+package crypt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranscoder(t *testing.T) {
+	plain := bytes.Repeat([]byte("transcode"), copyChunk/4)
+	plain = plain[:len(plain)-len(plain)%Block]
+
+	src, err := EncodePadded(MapKey, append([]byte{}, plain...))
+	require.NoError(t, err)
+	exp, err := EncodePadded(SaveKey, append([]byte{}, plain...))
+	require.NoError(t, err)
+
+	tr, err := NewTranscoder(MapKey, SaveKey)
+	require.NoError(t, err)
+	tr.ComputeCRC = true
+	var dst bytes.Buffer
+	n, err := tr.Transcode(&dst, bytes.NewReader(src))
+	require.NoError(t, err)
+	require.EqualValues(t, len(exp), n)
+	require.Equal(t, exp, dst.Bytes())
+
+	h := NewCRC()
+	h.Write(plain)
+	require.Equal(t, h.Sum32(), tr.CRC())
+
+	dst.Reset()
+	tr, err = NewTranscoder(SaveKey, NoKey)
+	require.NoError(t, err)
+	_, err = tr.Transcode(&dst, bytes.NewReader(exp[:len(exp)-3]))
+	require.ErrorIs(t, err, ErrUnaligned)
+	require.Equal(t, plain[:len(plain)-Block], dst.Bytes())
+}
+
+func TestRecrypt(t *testing.T) {
+	plain := []byte("recrypt test data")
+	src, err := EncodePadded(ThingBin, append([]byte{}, plain...))
+	require.NoError(t, err)
+
+	var dst bytes.Buffer
+	n, err := Recrypt(&dst, bytes.NewReader(src), ThingBin, NoKey)
+	require.NoError(t, err)
+	require.EqualValues(t, 3*Block, n)
+	require.Equal(t, plain, bytes.TrimRight(dst.Bytes(), "\x00"))
+
+	_, err = Recrypt(&dst, bytes.NewReader(src), ThingBin, Key(100))
+	require.ErrorIs(t, err, ErrInvalidKey)
+}
@@ -0,0 +1,41 @@
+package crypt
+
+// TestVector is a known-answer test for one of the Nox keys.
+type TestVector struct {
+	Key   Key    // key index
+	Plain []byte // decoded data
+	Data  []byte // encoded data
+	CRC   uint32 // CRC of the decoded data, as reported by Writer.CRC
+}
+
+const testVectorPlain = "ROLF\x01\x00\x00\x00\x03\x4d\x75\x64\x3e\x20\x03\x00\x08\x00\x00\x00\x00\x00\x00\x00"
+
+var testVectors = []struct {
+	key  Key
+	data string
+	crc  uint32
+}{
+	{SoundSetBin, "\xfa\xb7\x43\x5e\x1e\xfd\x2c\xcc\x64\xe9\x1d\x56\xdc\x36\x17\x25\xea\xbd\x21\xb4\xe0\x93\x61\x4e", 0x6de42a8d},
+	{ThingBin, "\x2c\xc3\x70\x31\x5e\xda\x12\x3c\x49\x28\x27\x6d\x9a\x62\x8e\x94\x95\x36\x9f\xa2\x0d\xd0\x04\xee", 0x6de42a8d},
+	{GameDataBin, "\xb9\x39\x9f\xcf\x9d\xe6\x8e\x7c\xd3\xc9\x6c\xa0\x6b\xcd\x1d\xcd\x93\xdc\x5b\x93\x5f\xf5\xdc\x38", 0x6de42a8d},
+	{ModifierBin, "\x63\xc6\xc2\x63\x07\x90\x2c\x13\x36\xb8\x1f\x43\x73\x5b\x95\x6c\x05\x84\x1c\x83\x4c\xd8\xb9\x4b", 0x6de42a8d},
+	{MapKey, "\x89\xe2\x86\x77\x35\xac\x2d\xae\xe0\xc9\x74\x9d\xea\x58\xef\x0d\xd8\xa2\x22\xca\x34\xc3\x06\xda", 0x6de42a8d},
+	{MonsterBin, "\x6b\x5b\x20\x14\xf4\x4b\x85\x55\x54\x0b\xc2\xf1\xa5\x80\x80\x32\x5e\x4c\xfc\xf7\x7b\x60\x62\x67", 0x6de42a8d},
+	{SaveKey, "\x9d\x3b\x66\x46\x48\x87\xad\xf8\xfc\x7e\x64\x70\x1b\x97\xc9\xc8\x0e\x36\x6f\x09\x86\xe8\x5a\x4f", 0x6de42a8d},
+}
+
+// TestVectors returns known-answer tests for all known Nox keys.
+// It can be used to validate other implementations of the encoding.
+// The returned slices can be modified by the caller.
+func TestVectors() []TestVector {
+	out := make([]TestVector, 0, len(testVectors))
+	for _, v := range testVectors {
+		out = append(out, TestVector{
+			Key:   v.key,
+			Plain: []byte(testVectorPlain),
+			Data:  []byte(v.data),
+			CRC:   v.crc,
+		})
+	}
+	return out
+}
@@ -0,0 +1,29 @@
+package crypt
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestVectors(t *testing.T) {
+	for _, v := range TestVectors() {
+		t.Run(fmt.Sprint(v.Key), func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			w, err := NewWriter(buf, v.Key)
+			require.NoError(t, err)
+			_, err = w.Write(v.Plain)
+			require.NoError(t, err)
+			err = w.Close()
+			require.NoError(t, err)
+			require.Equal(t, v.Data, buf.Bytes())
+			require.Equal(t, v.CRC, w.CRC())
+
+			err = Decode(v.Data, v.Key)
+			require.NoError(t, err)
+			require.Equal(t, v.Plain, v.Data)
+		})
+	}
+}
@@ -3,34 +3,149 @@ package crypt
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
+	"math"
+	"math/rand"
+	"os"
+	"time"
 
 	"golang.org/x/crypto/blowfish"
 )
 
 // NewWriter creates an encoder with a given key and a destination writer.
-func NewWriter(w io.Writer, key int) (*Writer, error) {
+func NewWriter(w io.Writer, key Key, opts ...Option) (*Writer, error) {
 	c, err := NewCipher(key)
 	if err != nil {
 		return nil, err
 	}
+	return newWriter(w, c, opts), nil
+}
+
+// NewWriterBytes is similar to NewWriter, but uses custom key material instead of the Nox key table.
+func NewWriterBytes(w io.Writer, key []byte, opts ...Option) (*Writer, error) {
+	c, err := NewCipherBytes(key)
+	if err != nil {
+		return nil, err
+	}
+	return newWriter(w, c, opts), nil
+}
+
+// OpenAppend opens an existing encrypted file and returns a Writer that continues writing at the end of it.
+// Closing the Writer closes the file as well, unless Reset is called.
+//
+// The file size must be a multiple of Block. Existing blocks are decoded to restore the CRC,
+// so that the result of Writer.CRC is the same as if the whole file was written by a single Writer.
+func OpenAppend(path string, key Key, opts ...Option) (*Writer, error) {
+	c, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	w, err := resumeWriter(f, c, opts)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	w.closer = f
+	return w, nil
+}
+
+func resumeWriter(f *os.File, c *blowfish.Cipher, opts []Option) (*Writer, error) {
+	w := newWriter(f, c, opts)
+	h := NewCRC()
+	var src io.Reader = f
+	if w.crcMode != CRCPlain {
+		src = io.TeeReader(f, h)
+	}
+	r := newReader(src, c, nil)
+	size, err := io.Copy(io.Discard, r)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("%w: file size is not a multiple of block size", ErrUnaligned)
+	} else if err != nil {
+		return nil, err
+	}
+	if _, err = seek(f, size, io.SeekStart); err != nil {
+		return nil, err
+	}
+	w.off = size
+	switch w.crcMode {
+	case CRCPlain:
+		w.crc = r.CRC()
+	case CRCCipher:
+		w.crc = h.Sum32()
+	case CRCBoth:
+		w.crc = r.CRC()
+		w.ccrc = h.Sum32()
+	}
+	return w, nil
+}
+
+func newWriter(w io.Writer, c *blowfish.Cipher, opts []Option) *Writer {
 	wr := &Writer{c: c}
+	o := options{w: wr}
+	o.apply(opts)
 	wr.Reset(w)
-	return wr, nil
+	return wr
 }
 
+// Writer encodes the data and writes it to an underlying writer.
+//
+// Writer is not safe for concurrent use, see SyncWriter. The only exception are WriteBlockAt
+// and similar methods, which can be called concurrently, as long as the underlying writer allows it.
+// In particular, it is safe to use WriteBlockAt together with Reader.ReadAt on the same os.File.
 type Writer struct {
-	w   io.Writer
-	at  io.WriterAt
-	c   *blowfish.Cipher
-	buf [Block]byte
-	n   int
-	off int64
-	crc uint32
+	w       io.Writer
+	at      io.WriterAt
+	c       *blowfish.Cipher
+	buf     [Block]byte
+	n       int
+	off     int64
+	crc     uint32
+	ccrc    uint32 // CRC of encoded data in CRCBoth mode
+	crcMode CRCMode
+	maxOff  int64
+	closed  bool
+	cerr    error
+	err     error // sticky error from the underlying writer
+	pad     padMode
+	padVal  byte
+	seed    int64
+	rnd     *rand.Rand
+	pend    []byte // encoded data that wasn't written due to an error
+	zero    []byte // encoded zero block, see WriteZeros
+	tmp     []byte // scratch buffer for bulk writes
 	// NoZero is a compatibility flag that forces the writer to not cleanup internal buffer with zeros.
 	// The result is that short writes followed by Flush may expose data from previous long writes.
 	// It is needed to keep 1:1 output from the original game engine.
 	NoZero bool
+	// StrictClose makes Close fail with ErrUnaligned if a partial block is pending, instead of padding it.
+	// It helps to catch serializer bugs in formats where the data must be a multiple of the block size.
+	StrictClose bool
+	// CRCTrailer makes Close append the CRC of all written blocks as a final block, see Reader.CRCTrailer.
+	// The trailer itself is not included in CRC. Note that blocks reserved by WriteEmpty are included in the
+	// trailer checksum as zeros, thus filling them later (for example, with WriteLengthHeader) fails the verification.
+	CRCTrailer bool
+	// SkipEmptyCRC excludes blocks reserved by WriteEmptyN from the CRC.
+	// By default, reserved blocks are included as zero blocks, same as in WriteEmpty.
+	SkipEmptyCRC bool
+	// TrackPlaceholders enables recording of all blocks reserved by WriteEmpty, WriteEmptyN and WriteEmptyNamed.
+	// The list can be retrieved with Manifest or written with WriteManifest.
+	TrackPlaceholders bool
+
+	manifest  []Placeholder
+	sections  []int64   // offsets of size blocks of open sections
+	lenHdr    int64     // offset of the length header block, or -1, see WriteLengthHeader
+	closer    io.Closer // set by OpenAppend and WriteFS
+	progress  func(done, total int64)
+	progTotal int64
+	progDone  int64
+	trace     BlockTraceFunc
+	stats     Stats
+	timing    bool
 }
 
 // Reset internal state and assign a new underlying writer to it.
@@ -39,12 +154,95 @@ func (w *Writer) Reset(d io.Writer) {
 	w.at, _ = d.(io.WriterAt)
 	w.n = 0
 	w.off = 0
+	w.pend = w.pend[:0]
+	w.manifest = nil
+	w.sections = w.sections[:0]
+	w.lenHdr = -1
+	w.closer = nil
+	w.progDone = 0
+	w.stats = Stats{}
+	w.closed = false
+	w.cerr = nil
+	w.err = nil
+	if w.pad == padRandom {
+		w.rnd = rand.New(rand.NewSource(w.seed))
+	}
 	w.ResetCRC()
 }
 
+type padMode int
+
+const (
+	padZero = padMode(iota)
+	padRandom
+	padByte
+	padRepeat
+	padPKCS7
+)
+
+// SetPadding makes Flush fill the rest of the partial block with a given byte instead of zeros.
+// Zero value restores the default zero padding.
+func (w *Writer) SetPadding(b byte) {
+	w.pad = padByte
+	if b == 0 {
+		w.pad = padZero
+	}
+	w.padVal = b
+}
+
+// SetRepeatPadding makes Flush fill the rest of the partial block with copies of the last written byte.
+func (w *Writer) SetRepeatPadding() {
+	w.pad = padRepeat
+}
+
+// SetPKCS7Padding enables PKCS#7 padding: each padding byte holds the number of padding bytes,
+// and Close always adds the padding, even if the data is aligned. Reader.PKCS7 strips it back,
+// which restores the exact length of the data without an external length field.
+// Flush should not be called in the middle of the stream in this mode, since the padding can only be stripped
+// from the final block.
+func (w *Writer) SetPKCS7Padding() {
+	w.pad = padPKCS7
+}
+
+// SetRandomPadding makes Flush fill the rest of the partial block with pseudo-random bytes instead of zeros.
+// Bytes are generated by a deterministic generator with a given seed, which is restarted by Reset.
+// Thus, the output remains reproducible for the same input.
+func (w *Writer) SetRandomPadding(seed int64) {
+	w.pad = padRandom
+	w.seed = seed
+	w.rnd = rand.New(rand.NewSource(seed))
+}
+
+// padBuf fills the rest of the buffer according to the padding mode.
+func (w *Writer) padBuf() {
+	switch w.pad {
+	case padRandom:
+		w.rnd.Read(w.buf[w.n:])
+	case padByte, padRepeat:
+		b := w.padVal
+		if w.pad == padRepeat {
+			b = w.buf[w.n-1]
+		}
+		for i := w.n; i < len(w.buf); i++ {
+			w.buf[i] = b
+		}
+	case padPKCS7:
+		b := byte(len(w.buf) - w.n)
+		for i := w.n; i < len(w.buf); i++ {
+			w.buf[i] = b
+		}
+	default:
+		if !w.NoZero {
+			var empty [Block]byte
+			copy(w.buf[w.n:], empty[:])
+		}
+	}
+}
+
 // ResetCRC resets CRC internal state.
 func (w *Writer) ResetCRC() {
 	w.crc = ZeroCRC
+	w.ccrc = ZeroCRC
 }
 
 // CRC returns current CRC checksum.
@@ -52,6 +250,87 @@ func (w *Writer) CRC() uint32 {
 	return w.crc
 }
 
+// CipherCRC returns current CRC checksum of encoded data. It's only available in CRCBoth mode,
+// see SetCRCMode, and returns ZeroCRC otherwise.
+func (w *Writer) CipherCRC() uint32 {
+	return w.ccrc
+}
+
+// SetCRCMode selects whether CRC covers the data as written by the caller (CRCPlain, the default)
+// or the encoded data written to the underlying writer (CRCCipher). In CRCBoth mode, CRC covers the data
+// written by the caller and CipherCRC covers the encoded data. It should be called before writing any data.
+// Blocks reserved by WriteEmpty are written as zeros and are included as zeros in all modes.
+func (w *Writer) SetCRCMode(m CRCMode) {
+	w.crcMode = m
+}
+
+// updateCRC updates the checksums with a block, given in both decoded and encoded form.
+func (w *Writer) updateCRC(plain, enc []byte) {
+	switch w.crcMode {
+	case CRCCipher:
+		w.crc = UpdateCRC(w.crc, enc)
+	case CRCBoth:
+		w.crc = UpdateCRC(w.crc, plain)
+		w.ccrc = UpdateCRC(w.ccrc, enc)
+	default:
+		w.crc = UpdateCRC(w.crc, plain)
+	}
+}
+
+// SetMaxOffset sets the maximal offset accepted by WriteBlockAt and similar methods.
+// Zero resets the limit to DefaultMaxOffset, negative value disables it.
+func (w *Writer) SetMaxOffset(n int64) {
+	w.maxOff = n
+}
+
+// MaxOffset returns current offset limit. See SetMaxOffset.
+func (w *Writer) MaxOffset() int64 {
+	if w.maxOff == 0 {
+		return DefaultMaxOffset
+	}
+	return w.maxOff
+}
+
+// DebugState returns a human-readable description of the internal state of the Writer.
+// It is intended for bug reports and the format may change at any time.
+func (w *Writer) DebugState() string {
+	return fmt.Sprintf("crypt.Writer{written: %d, buffered: %d, crc: %#08x, encrypted: %v, noZero: %v, strictClose: %v, randomPadding: %v, closed: %v, maxOffset: %d, writerAt: %v}",
+		w.off, w.n, w.crc, w.c != nil, w.NoZero, w.StrictClose, w.pad == padRandom, w.closed, w.MaxOffset(), w.at != nil)
+}
+
+// Pending returns the number of bytes in the current block that are not yet flushed.
+// It is always less than Block.
+func (w *Writer) Pending() int {
+	return w.n
+}
+
+// Buffered returns the number of bytes in the current block that are not yet flushed, same as Pending.
+// It mirrors bufio.Writer.Buffered.
+func (w *Writer) Buffered() int {
+	return w.n
+}
+
+// FlushPadding returns the number of padding bytes the next Flush would add to complete the current block.
+// It returns zero if the data is already aligned to the block size.
+func (w *Writer) FlushPadding() int {
+	if w.n == 0 {
+		return 0
+	}
+	return Block - w.n
+}
+
+// Available returns how many bytes can be written before the current block is flushed.
+func (w *Writer) Available() int {
+	return Block - w.n
+}
+
+// AvailableBuffer returns an empty buffer with Available capacity, same as bufio.Writer.AvailableBuffer.
+// This buffer is intended to be appended to and passed to an immediately succeeding Write call.
+// The buffer is only valid until the next write operation on w.
+func (w *Writer) AvailableBuffer() []byte {
+	return w.buf[w.n:][:0]
+}
+
 // Written returns a number of bytes written.
 // It will differ from the actual number of written bytes unless Flush is called.
 func (w *Writer) Written() int64 {
@@ -59,37 +338,253 @@ func (w *Writer) Written() int64 {
 }
 
 func (w *Writer) flush() error {
-	w.crc = UpdateCRC(w.crc, w.buf[:])
 	var dst [Block]byte
 	if w.c != nil {
+		t := startTimer(w.timing)
 		w.c.Encrypt(dst[:], w.buf[:])
+		stopTimer(&w.stats.CipherTime, t)
 	} else {
 		copy(dst[:], w.buf[:])
 	}
-	_, err := w.w.Write(dst[:])
+	w.updateCRC(w.buf[:], dst[:])
+	if w.trace != nil {
+		w.trace(w.off-int64(w.n), dst[:], w.buf[:])
+	}
+	w.stats.Blocks++
+	err := w.writeRaw(dst[:])
 	w.off += int64(Block - w.n)
 	w.n = 0
 	return err
 }
 
+// bulkMin is the minimal size of the write that bypasses the internal buffer.
+const bulkMin = 8 * Block
+
+// writeBlocks encodes whole blocks from p into dst and writes them to the underlying writer with a single call.
+// The internal buffer must be empty. Buffers p and dst may be the same.
+func (w *Writer) writeBlocks(dst, p []byte) error {
+	if w.crcMode != CRCCipher {
+		// p and dst may be the same, so decoded data is hashed before encoding
+		w.crc = updateCRCBlocks(w.crc, p)
+	}
+	// keep the internal buffer in the same state as if blocks were written one by one
+	if w.NoZero {
+		clear(w.buf[:])
+	} else {
+		copy(w.buf[:], p[len(p)-Block:])
+	}
+	dst = dst[:len(p)]
+	if w.trace != nil {
+		w.traceBlocks(dst, p)
+	} else if w.c != nil {
+		t := startTimer(w.timing)
+		for i := 0; i < len(p); i += Block {
+			w.c.Encrypt(dst[i:i+Block], p[i:i+Block])
+		}
+		stopTimer(&w.stats.CipherTime, t)
+	} else {
+		copy(dst, p)
+	}
+	switch w.crcMode {
+	case CRCCipher:
+		w.crc = updateCRCBlocks(w.crc, dst)
+	case CRCBoth:
+		w.ccrc = updateCRCBlocks(w.ccrc, dst)
+	}
+	w.stats.Blocks += int64(len(p) / Block)
+	w.off += int64(len(p))
+	return w.writeRaw(dst)
+}
+
+// traceBlocks encodes blocks from p into dst and reports each of them to the trace callback.
+func (w *Writer) traceBlocks(dst, p []byte) {
+	var dec [Block]byte
+	for i := 0; i < len(p); i += Block {
+		copy(dec[:], p[i:i+Block])
+		if w.c != nil {
+			w.c.Encrypt(dst[i:i+Block], dec[:])
+		} else {
+			copy(dst[i:i+Block], dec[:])
+		}
+		w.trace(w.off+int64(i), dst[i:i+Block], dec[:])
+	}
+}
+
+// writeBulk is similar to Write, but encodes and writes all whole blocks of p at once.
+// Buffer p is used for the encoded data and its contents is undefined after the call.
+func (w *Writer) writeBulk(p []byte) (int, error) {
+	total := 0
+	for w.n != 0 && len(p) > 0 {
+		n, err := w.write(p)
+		total += n
+		if err != nil {
+			return total, err
+		}
+		p = p[n:]
+	}
+	if n := len(p) - len(p)%Block; n != 0 {
+		if err := w.writeBlocks(p[:n], p[:n]); err != nil {
+			return total, err
+		}
+		total += n
+		p = p[n:]
+	}
+	if len(p) != 0 {
+		n, err := w.write(p)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// writeRaw writes encoded data to the underlying writer. Data that wasn't written due to an error
+// (for example, due to a deadline) is kept and will be written first on the next call.
+func (w *Writer) writeRaw(p []byte) error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.pend) != 0 {
+		if err := w.writePending(); err != nil {
+			w.pend = append(w.pend, p...)
+			return err
+		}
+	}
+	t := startTimer(w.timing)
+	n, err := w.w.Write(p)
+	stopTimer(&w.stats.IOTime, t)
+	w.stats.Calls++
+	if err != nil {
+		w.pend = append(w.pend, p[n:]...)
+	}
+	if w.progress != nil && n > 0 {
+		w.reportProgress(n)
+	}
+	return w.setErr(wrapIO("write", err))
+}
+
+func (w *Writer) writePending() error {
+	t := startTimer(w.timing)
+	n, err := w.w.Write(w.pend)
+	stopTimer(&w.stats.IOTime, t)
+	w.stats.Calls++
+	if w.progress != nil && n > 0 {
+		w.reportProgress(n)
+	}
+	w.pend = w.pend[:copy(w.pend, w.pend[n:])]
+	return w.setErr(wrapIO("write", err))
+}
+
+// setErr remembers the error returned by the underlying writer. Timeout errors are not stored,
+// since the write can be retried after the deadline is updated.
+func (w *Writer) setErr(err error) error {
+	if err == nil || w.err != nil {
+		return err
+	}
+	var t interface{ Timeout() bool }
+	if errors.As(err, &t) && t.Timeout() {
+		return err
+	}
+	w.err = err
+	return err
+}
+
+// Err returns the error that was returned by the underlying writer.
+// Once the error is set, all subsequent writes and flushes return it, since the offset and the CRC
+// no longer match the written data. Timeout errors are not stored, see SetWriteDeadline.
+// Reset clears the error.
+func (w *Writer) Err() error {
+	return w.err
+}
+
+// SetWriteDeadline sets the write deadline on the underlying writer, for example net.Conn.
+// If the deadline is exceeded, the data that wasn't written is kept and written on the next Write or Flush.
+func (w *Writer) SetWriteDeadline(t time.Time) error {
+	d, ok := w.w.(interface {
+		SetWriteDeadline(t time.Time) error
+	})
+	if !ok {
+		return errDeadlineUnsupported
+	}
+	return d.SetWriteDeadline(t)
+}
+
 // Flush buffered data to the underlying writer. The data will be aligned to the block size.
+//
+// Flush is a no-op if there's no buffered data, thus it never writes padding-only blocks,
+// but it retries writing the data that previously failed to be written (for example, due to a deadline),
+// and it's safe to call it multiple times.
 func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
 	if w.n == 0 {
+		if len(w.pend) != 0 {
+			return w.writePending()
+		}
 		return nil
 	}
-	if !w.NoZero && w.n != len(w.buf) {
-		var empty [Block]byte
-		copy(w.buf[w.n:], empty[:])
+	if w.n != len(w.buf) {
+		w.padBuf()
+		w.stats.Padding += int64(len(w.buf) - w.n)
+		w.stats.Flushes++
 	}
 	return w.flush()
 }
 
 // Close flushes the data. See Flush.
+//
+// It is safe to call Close multiple times: subsequent calls do nothing and return the result of the first call.
+// After Close, writes fail with ErrClosed, but WriteBlockAt and similar methods can still be used
+// to fill blocks reserved by WriteEmpty. Reset makes the Writer usable again.
+// If the Writer was created by OpenAppend or WriteFS, Close closes the file as well.
 func (w *Writer) Close() error {
-	return w.Flush()
+	if w.closed {
+		return w.cerr
+	}
+	w.closed = true
+	size := w.off - w.lenHdr - Block
+	if w.StrictClose && w.n != 0 {
+		w.cerr = fmt.Errorf("%w: %d bytes pending", ErrUnaligned, w.n)
+	} else if w.pad == padPKCS7 && w.n == 0 && w.err == nil {
+		// aligned data still needs a padding block
+		w.padBuf()
+		w.stats.Padding += Block
+		w.stats.Flushes++
+		w.cerr = w.flush()
+	} else {
+		w.cerr = w.Flush()
+	}
+	if w.CRCTrailer && w.cerr == nil {
+		w.cerr = w.writeCRCTrailer()
+	}
+	if w.lenHdr >= 0 && w.cerr == nil {
+		w.cerr = w.WriteU64At(uint64(size), w.lenHdr)
+	}
+	if c := w.closer; c != nil {
+		w.closer = nil
+		if err := c.Close(); w.cerr == nil {
+			w.cerr = err
+		}
+	}
+	return w.cerr
+}
+
+// writeCRCTrailer writes current CRC as a separate block, without including it in the CRC.
+func (w *Writer) writeCRCTrailer() error {
+	crc, ccrc := w.crc, w.ccrc
+	w.buf = [Block]byte{}
+	binary.LittleEndian.PutUint32(w.buf[:4], crc)
+	err := w.flush()
+	w.crc, w.ccrc = crc, ccrc
+	return err
 }
 
 func (w *Writer) write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
 	n := copy(w.buf[w.n:], p)
 	w.n += n
 	w.off += int64(n)
@@ -107,7 +602,25 @@ func (w *Writer) write(p []byte) (int, error) {
 
 // Write implements io.Writer.
 func (w *Writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, ErrClosed
+	}
 	total := 0
+	if w.n == 0 && len(p) >= bulkMin {
+		// encode whole blocks directly from the input
+		if w.tmp == nil {
+			w.tmp = make([]byte, copyChunk)
+		}
+		for len(p) >= Block {
+			n := min(len(p), len(w.tmp))
+			n -= n % Block
+			if err := w.writeBlocks(w.tmp, p[:n]); err != nil {
+				return total, err
+			}
+			total += n
+			p = p[n:]
+		}
+	}
 	for len(p) > 0 {
 		n, err := w.write(p)
 		total += n
@@ -119,11 +632,63 @@ func (w *Writer) Write(p []byte) (int, error) {
 	return total, nil
 }
 
-func (w *Writer) WriteU8(v byte) error {
-	_, err := w.Write([]byte{v})
+// WriteByte implements io.ByteWriter.
+func (w *Writer) WriteByte(v byte) error {
+	if w.closed {
+		return ErrClosed
+	}
+	b := [1]byte{v}
+	_, err := w.write(b[:])
 	return err
 }
 
+// WriteString implements io.StringWriter. Unlike Write, it doesn't require converting the string to a byte slice.
+func (w *Writer) WriteString(s string) (int, error) {
+	if w.closed {
+		return 0, ErrClosed
+	}
+	total := 0
+	for len(s) > 0 {
+		var b [Block]byte
+		n, err := w.write(b[:copy(b[:Block-w.n], s)])
+		total += n
+		if err != nil {
+			return total, err
+		}
+		s = s[n:]
+	}
+	return total, nil
+}
+
+// ReadFrom implements io.ReaderFrom. It reads the data in large chunks, encodes them
+// and writes each chunk to the underlying writer with a single call.
+func (w *Writer) ReadFrom(r io.Reader) (int64, error) {
+	if w.closed {
+		return 0, ErrClosed
+	}
+	buf := make([]byte, copyChunk)
+	var total int64
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			m, werr := w.writeBulk(buf[:n])
+			total += int64(m)
+			if werr != nil {
+				return total, werr
+			}
+		}
+		if err == io.EOF {
+			return total, nil
+		} else if err != nil {
+			return total, err
+		}
+	}
+}
+
+func (w *Writer) WriteU8(v byte) error {
+	return w.WriteByte(v)
+}
+
 func (w *Writer) WriteU16(v uint16) error {
 	var b [2]byte
 	binary.LittleEndian.PutUint16(b[:], v)
@@ -161,35 +726,301 @@ func (w *Writer) WriteI64(v int64) error {
 	return w.WriteU64(uint64(v))
 }
 
+// WriteFixed writes p as a fixed-size field of n bytes, padded with zeros.
+// If p is longer than n, ErrInvalidSize is returned and nothing is written.
+func (w *Writer) WriteFixed(p []byte, n int) error {
+	if len(p) > n {
+		return fmt.Errorf("%w: field size %d exceeds %d", ErrInvalidSize, len(p), n)
+	}
+	if _, err := w.Write(p); err != nil {
+		return err
+	}
+	return w.WriteZeros(int64(n - len(p)))
+}
+
+// WriteF32 writes IEEE 754 float32 value.
+func (w *Writer) WriteF32(v float32) error {
+	return w.WriteU32(math.Float32bits(v))
+}
+
+// WriteF64 writes IEEE 754 float64 value.
+func (w *Writer) WriteF64(v float64) error {
+	return w.WriteU64(math.Float64bits(v))
+}
+
 // WriteEmpty flushes the data (if any), which aligns it to a block size,
 // and then writes an additional empty block without encryption.
 // This block can be later written with WriteBlockAt, WriteU64At, WriteU32At, etc.
 func (w *Writer) WriteEmpty() (int64, error) {
+	if w.closed {
+		return 0, ErrClosed
+	}
 	if err := w.Flush(); err != nil {
 		return 0, err
 	}
 	var empty [Block]byte
-	w.crc = UpdateCRC(w.crc, empty[:])
-	_, err := w.w.Write(empty[:])
+	w.updateCRC(empty[:], empty[:])
+	err := w.writeRaw(empty[:])
 	off := w.off
 	w.off += Block
+	w.track("", off, Block)
 	return off, err
 }
 
+// Truncate flushes the data and truncates the underlying file to a given size, which must be a multiple of Block.
+// It requires the underlying writer to implement Truncate method, as os.File does.
+//
+// If the size is less than the current offset, the writer will continue writing at the new end of the file.
+// This requires the underlying writer to implement io.Seeker. Note that the CRC is not updated by Truncate.
+func (w *Writer) Truncate(size int64) error {
+	t, ok := w.w.(interface {
+		Truncate(size int64) error
+	})
+	if !ok {
+		return errTruncateUnsupported
+	}
+	if size < 0 {
+		return ErrNegativeOffset
+	}
+	if size%Block != 0 {
+		return fmt.Errorf("%w: truncate to %d", ErrUnaligned, size)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := t.Truncate(size); err != nil {
+		return wrapIO("truncate", err)
+	}
+	if w.off > size {
+		s, ok := w.w.(io.Seeker)
+		if !ok {
+			return errSeekUnsupported
+		}
+		if _, err := seek(s, size, io.SeekStart); err != nil {
+			return err
+		}
+		w.off = size
+	}
+	return nil
+}
+
+// Seek implements io.Seeker. It flushes buffered data and moves the write offset, which allows overwriting
+// blocks that were already written. The resulting offset must be a multiple of Block.
+// It requires the underlying writer to implement io.Seeker.
+//
+// Seek doesn't change the CRC, which continues to accumulate all blocks written after the seek.
+func (w *Writer) Seek(off int64, whence int) (int64, error) {
+	s, ok := w.w.(io.Seeker)
+	if !ok {
+		return 0, errSeekUnsupported
+	}
+	if w.closed {
+		return 0, ErrClosed
+	}
+	if off == 0 && whence == io.SeekCurrent {
+		return w.off, nil
+	}
+	if err := w.Flush(); err != nil {
+		return 0, err
+	}
+	off, err := seekTarget(s, off, whence, func() (int64, error) {
+		return w.off, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if off%Block != 0 {
+		return 0, fmt.Errorf("%w: seek to %d", ErrUnaligned, off)
+	}
+	if err = checkOffset(off, w.MaxOffset()); err != nil {
+		return 0, err
+	}
+	if _, err = seek(s, off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	w.off = off
+	return off, nil
+}
+
+// WriteZeros writes n zero bytes. It is faster than writing zeros with Write,
+// since full zero blocks are encoded only once.
+func (w *Writer) WriteZeros(n int64) error {
+	if w.closed {
+		return ErrClosed
+	}
+	if n < 0 {
+		return fmt.Errorf("%w: negative size %d", ErrInvalidSize, n)
+	}
+	var empty [Block]byte
+	if w.n != 0 && n > 0 {
+		sz := min(n, int64(Block-w.n))
+		if _, err := w.write(empty[:sz]); err != nil {
+			return err
+		}
+		n -= sz
+	}
+	if blocks := n / Block; blocks > 0 {
+		if w.zero == nil {
+			w.zero = make([]byte, Block)
+			if w.c != nil {
+				w.c.Encrypt(w.zero, empty[:])
+			}
+		}
+		chunk := make([]byte, min(blocks, 8<<10)*Block)
+		for i := 0; i < len(chunk); i += Block {
+			copy(chunk[i:], w.zero)
+		}
+		for blocks > 0 {
+			cnt := min(blocks, int64(len(chunk)/Block))
+			for i := int64(0); i < cnt; i++ {
+				w.updateCRC(empty[:], w.zero)
+				if w.trace != nil {
+					w.trace(w.off+i*Block, w.zero, empty[:])
+				}
+			}
+			w.stats.Blocks += cnt
+			err := w.writeRaw(chunk[:cnt*Block])
+			w.off += cnt * Block
+			if err != nil {
+				return err
+			}
+			blocks -= cnt
+		}
+		n %= Block
+	}
+	if n > 0 {
+		if _, err := w.write(empty[:n]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sparseMin is the minimal size of the region reserved by WriteEmptyN that will be stored as a hole.
+const sparseMin = 1 << 20
+
+// WriteEmptyN flushes the data (if any), which aligns it to a block size,
+// and then reserves n empty blocks without encryption. It returns the offset of the first block.
+// These blocks can be later written with WriteAt, WriteBlockAt, etc.
+//
+// Large regions are not written when appending to a file that supports Seek and Truncate, such as os.File.
+// Instead, the file is extended, which creates a hole on most file systems.
+// See SkipEmptyCRC for the effect of this method on CRC.
+func (w *Writer) WriteEmptyN(n int) (int64, error) {
+	return w.WriteEmptyNamed("", n)
+}
+
+// WriteEmptyNamed is the same as WriteEmptyN, but also records the name of the placeholder
+// in the manifest, if TrackPlaceholders is set.
+func (w *Writer) WriteEmptyNamed(name string, n int) (int64, error) {
+	if w.closed {
+		return 0, ErrClosed
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("%w: negative block count %d", ErrInvalidSize, n)
+	}
+	if err := w.Flush(); err != nil {
+		return 0, err
+	}
+	off := w.off
+	size := int64(n) * Block
+	if err := checkOffset(off+size, w.MaxOffset()); err != nil {
+		return 0, err
+	}
+	if !w.SkipEmptyCRC {
+		var empty [Block]byte
+		for i := 0; i < n; i++ {
+			w.updateCRC(empty[:], empty[:])
+		}
+	}
+	if size >= sparseMin && len(w.pend) == 0 {
+		if ok, err := w.skipSparse(size); err != nil {
+			return 0, err
+		} else if ok {
+			w.off += size
+			w.track(name, off, size)
+			return off, nil
+		}
+	}
+	empty := make([]byte, min(size, 64<<10))
+	for rem := size; rem > 0; {
+		sz := min(rem, int64(len(empty)))
+		if err := w.writeRaw(empty[:sz]); err != nil {
+			return 0, err
+		}
+		rem -= sz
+		w.off += sz
+	}
+	w.track(name, off, size)
+	return off, nil
+}
+
+// skipSparse extends the underlying file by a given size without writing any data.
+// It returns false if it's not possible, for example when overwriting file contents.
+func (w *Writer) skipSparse(size int64) (bool, error) {
+	f, ok := w.w.(interface {
+		io.Seeker
+		Truncate(size int64) error
+	})
+	if !ok {
+		return false, nil
+	}
+	cur, err := seek(f, 0, io.SeekCurrent)
+	if err != nil {
+		return false, err
+	}
+	end, err := seek(f, 0, io.SeekEnd)
+	if err != nil {
+		return false, err
+	}
+	if end > cur {
+		// existing data must be overwritten with zeros
+		_, err = seek(f, cur, io.SeekStart)
+		return false, err
+	}
+	if err = f.Truncate(cur + size); err != nil {
+		return false, wrapIO("truncate", err)
+	}
+	_, err = seek(f, cur+size, io.SeekStart)
+	return err == nil, err
+}
+
 // WriteBlockAt encrypts and writes a block at an offset, previously returned by WriteEmpty.
 // It requires the underlying writer to implement io.WriterAt.
 func (w *Writer) WriteBlockAt(buf [Block]byte, off int64) error {
+	_, err := w.WriteAt(buf[:], off)
+	return err
+}
+
+// WriteAt implements io.WriterAt. It encrypts and writes whole blocks at a given offset,
+// thus both the offset and the size of the data must be multiples of Block.
+// It requires the underlying writer to implement io.WriterAt.
+//
+// WriteAt doesn't change the state of the Writer, including the CRC.
+// It can be used to overwrite data that was already flushed, even after Close.
+func (w *Writer) WriteAt(p []byte, off int64) (int, error) {
 	if w.at == nil {
-		return errors.New("WriteAt is not supported by the underlying writer")
+		return 0, errWriteAtUnsupported
 	}
-	var dst [Block]byte
+	if off < 0 {
+		return 0, ErrNegativeOffset
+	}
+	if off%Block != 0 || len(p)%Block != 0 {
+		return 0, ErrUnaligned
+	}
+	if err := checkOffset(off+int64(len(p)), w.MaxOffset()); err != nil {
+		return 0, err
+	}
+	dst := make([]byte, len(p))
 	if w.c != nil {
-		w.c.Encrypt(dst[:], buf[:])
+		for i := 0; i < len(p); i += Block {
+			w.c.Encrypt(dst[i:i+Block], p[i:i+Block])
+		}
 	} else {
-		copy(dst[:], buf[:])
+		copy(dst, p)
 	}
-	_, err := w.at.WriteAt(dst[:], off)
-	return err
+	n, err := w.at.WriteAt(dst, off)
+	return n, wrapIO("write", err)
 }
 
 // WriteU64At encrypts and writes uint64 at an offset, previously returned by WriteEmpty.
@@ -2,6 +2,10 @@ package crypt
 
 import (
 	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -43,6 +47,7 @@ func TestWriter(t *testing.T) {
 	_, err = w.Write([]byte("1"))
 	require.NoError(t, err)
 	require.Equal(t, int64(1), w.Written())
+	require.Equal(t, 1, w.Pending())
 	require.Equal(t, int(0), buf.Len())
 
 	_, err = w.Write([]byte("2"))
@@ -53,6 +58,7 @@ func TestWriter(t *testing.T) {
 	err = w.Flush()
 	require.NoError(t, err)
 	require.Equal(t, int64(8), w.Written())
+	require.Equal(t, 0, w.Pending())
 	require.Equal(t, int(8), buf.Len())
 
 	_, err = w.WriteEmpty()
@@ -127,3 +133,617 @@ func TestWriterNoKey(t *testing.T) {
 	require.Equal(t, "12\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00",
 		string(buf.Bytes()), "%x", buf.Bytes())
 }
+
+func TestWriterClose(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf, NoKey)
+	require.NoError(t, err)
+
+	require.NoError(t, w.Flush())
+	require.Equal(t, 0, buf.Len())
+
+	_, err = w.Write([]byte("123"))
+	require.NoError(t, err)
+	require.NoError(t, w.Flush())
+	require.NoError(t, w.Flush())
+	require.Equal(t, 8, buf.Len())
+
+	_, err = w.Write([]byte("4"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, w.Close())
+	require.NoError(t, w.Flush())
+	require.Equal(t, 16, buf.Len())
+
+	_, err = w.Write([]byte("5"))
+	require.ErrorIs(t, err, ErrClosed)
+	_, err = w.WriteEmpty()
+	require.ErrorIs(t, err, ErrClosed)
+
+	w.Reset(errWriter{err: io.ErrShortWrite})
+	_, err = w.Write([]byte("1"))
+	require.NoError(t, err)
+	err = w.Close()
+	require.ErrorIs(t, err, io.ErrShortWrite)
+	require.Equal(t, err, w.Close())
+}
+
+func TestWriterStrictClose(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf, NoKey)
+	require.NoError(t, err)
+	w.StrictClose = true
+
+	_, err = w.Write([]byte("12345678"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.Equal(t, "12345678", buf.String())
+
+	buf.Reset()
+	w.Reset(buf)
+	_, err = w.Write([]byte("123"))
+	require.NoError(t, err)
+	err = w.Close()
+	require.ErrorIs(t, err, ErrUnaligned)
+	require.Equal(t, 0, buf.Len())
+}
+
+func TestWriterRandomPadding(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf, ThingBin)
+	require.NoError(t, err)
+	w.SetRandomPadding(42)
+
+	write := func() []byte {
+		buf.Reset()
+		w.Reset(buf)
+		_, err = w.Write([]byte("12345"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		out := bytes.Clone(buf.Bytes())
+		err = Decode(out, ThingBin)
+		require.NoError(t, err)
+		return out
+	}
+	out1 := write()
+	out2 := write()
+	require.Equal(t, out1, out2)
+	require.Equal(t, "12345", string(out1[:5]))
+	require.NotEqual(t, "\x00\x00\x00", string(out1[5:]))
+
+	w.SetRandomPadding(43)
+	out3 := write()
+	require.NotEqual(t, out1, out3)
+}
+
+func TestWriterPadding(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf, ThingBin, WithPadding(0xff))
+	require.NoError(t, err)
+	write := func() string {
+		buf.Reset()
+		w.Reset(buf)
+		_, err = w.Write([]byte("12345"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		out := bytes.Clone(buf.Bytes())
+		require.NoError(t, Decode(out, ThingBin))
+		return string(out)
+	}
+	require.Equal(t, "12345\xff\xff\xff", write())
+	w.SetRepeatPadding()
+	require.Equal(t, "12345555", write())
+	w.SetPadding(0)
+	require.Equal(t, "12345\x00\x00\x00", write())
+}
+
+func TestPKCS7Padding(t *testing.T) {
+	for _, n := range []int{0, 1, 5, 7, 8, 9, 16, 100} {
+		data := bytes.Repeat([]byte{'a'}, n)
+		buf := bytes.NewBuffer(nil)
+		w, err := NewWriter(buf, ThingBin, WithPKCS7(true))
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		require.Equal(t, (n/Block+1)*Block, buf.Len())
+
+		for _, ahead := range []int{-1, 0} {
+			r, err := NewReader(bytes.NewReader(buf.Bytes()), ThingBin, WithPKCS7(true), WithReadAhead(ahead))
+			require.NoError(t, err)
+			got, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.Equal(t, data, got)
+		}
+	}
+
+	// held back block is released with padding stripped, so the buffer end is unaligned
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf, ThingBin, WithPKCS7(true))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("abcdefghijk"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), ThingBin, WithPKCS7(true), WithReadAhead(-1))
+	require.NoError(t, err)
+	_, err = r.ReadU8()
+	require.NoError(t, err)
+	_, err = r.Peek(10)
+	require.NoError(t, err)
+	require.NoError(t, r.Align())
+	require.EqualValues(t, Block, r.Offset())
+	rest, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "ijk", string(rest))
+
+	enc := []byte("1234567\x02")
+	require.NoError(t, Encode(enc, ThingBin))
+	r, err = NewReader(bytes.NewReader(enc), ThingBin, WithPKCS7(true))
+	require.NoError(t, err)
+	_, err = io.ReadAll(r)
+	require.ErrorIs(t, err, ErrPadding)
+}
+
+func TestCRCTrailer(t *testing.T) {
+	for _, pkcs7 := range []bool{false, true} {
+		for _, n := range []int{0, 5, 8, 21} {
+			data := bytes.Repeat([]byte{'b'}, n)
+			buf := bytes.NewBuffer(nil)
+			w, err := NewWriter(buf, ThingBin, WithCRCTrailer(true), WithPKCS7(pkcs7))
+			require.NoError(t, err)
+			_, err = w.Write(data)
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+			enc := buf.Bytes()
+
+			r, err := NewReader(bytes.NewReader(enc), ThingBin, WithCRCTrailer(true), WithPKCS7(pkcs7), WithReadAhead(-1))
+			require.NoError(t, err)
+			got, err := io.ReadAll(r)
+			require.NoError(t, err)
+			if pkcs7 {
+				require.Equal(t, data, got)
+			} else {
+				require.Equal(t, data, got[:n])
+				require.Len(t, got, int(EncodedSize(int64(n))))
+			}
+
+			if n == 0 {
+				continue
+			}
+			bad := bytes.Clone(enc)
+			bad[0] ^= 1
+			r, err = NewReader(bytes.NewReader(bad), ThingBin, WithCRCTrailer(true), WithPKCS7(pkcs7))
+			require.NoError(t, err)
+			_, err = io.ReadAll(r)
+			require.ErrorIs(t, err, ErrChecksum)
+		}
+	}
+}
+
+func TestSkipHeldBlocks(t *testing.T) {
+	opts := []Option{WithPKCS7(true), WithCRCTrailer(true), WithReadAhead(-1)}
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf, ThingBin, opts...)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("AAAAAAAABBBBBBBBCCCCCCCC"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), ThingBin, opts...)
+	require.NoError(t, err)
+	b := make([]byte, Block)
+	_, err = io.ReadFull(r, b)
+	require.NoError(t, err)
+	require.Equal(t, "AAAAAAAA", string(b))
+	require.NoError(t, r.SkipBlocks(1))
+	require.EqualValues(t, 2*Block, r.Offset())
+	rest, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "CCCCCCCC", string(rest))
+}
+
+func TestWriterDebugState(t *testing.T) {
+	w, err := NewWriter(bytes.NewBuffer(nil), ThingBin)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("123"))
+	require.NoError(t, err)
+	require.Equal(t, "crypt.Writer{written: 3, buffered: 3, crc: 0xffffffff, encrypted: true, noZero: false, strictClose: false, randomPadding: false, closed: false, maxOffset: 1099511627776, writerAt: false}", w.DebugState())
+}
+
+type bufferAt struct {
+	buf []byte
+}
+
+func (b *bufferAt) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+func (b *bufferAt) WriteAt(p []byte, off int64) (int, error) {
+	if end := int(off) + len(p); end > len(b.buf) {
+		b.buf = append(b.buf, make([]byte, end-len(b.buf))...)
+	}
+	return copy(b.buf[off:], p), nil
+}
+
+func TestWriterWriteAt(t *testing.T) {
+	buf := &bufferAt{}
+	w, err := NewWriter(buf, NoKey)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("1234567890abcdef"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	_, err = w.WriteAt([]byte("xx"), 8)
+	require.ErrorIs(t, err, ErrUnaligned)
+	_, err = w.WriteAt([]byte("ABCDEFGH"), 4)
+	require.ErrorIs(t, err, ErrUnaligned)
+	n, err := w.WriteAt([]byte("ABCDEFGH"), 8)
+	require.NoError(t, err)
+	require.Equal(t, 8, n)
+	require.Equal(t, "12345678ABCDEFGH", string(buf.buf))
+}
+
+func TestWriterTruncate(t *testing.T) {
+	f, err := os.CreateTemp("", "crypt-truncate-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		f.Close()
+		os.Remove(f.Name())
+	}()
+
+	w, err := NewWriter(f, NoKey)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("1234567890abcdefgh"))
+	require.NoError(t, err)
+
+	require.ErrorIs(t, w.Truncate(12), ErrUnaligned)
+	require.NoError(t, w.Truncate(8))
+	require.Equal(t, int64(8), w.Written())
+	_, err = w.Write([]byte("xyz"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	data, err := os.ReadFile(f.Name())
+	require.NoError(t, err)
+	require.Equal(t, "12345678xyz\x00\x00\x00\x00\x00", string(data))
+
+	w.Reset(bytes.NewBuffer(nil))
+	require.ErrorIs(t, w.Truncate(0), errors.ErrUnsupported)
+}
+
+func TestWriterWriteEmptyN(t *testing.T) {
+	f, err := os.CreateTemp("", "crypt-sparse-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		f.Close()
+		os.Remove(f.Name())
+	}()
+	const n = 2 * sparseMin / Block
+
+	buf := bytes.NewBuffer(nil)
+	bw, err := NewWriter(buf, ThingBin)
+	require.NoError(t, err)
+	fw, err := NewWriter(f, ThingBin)
+	require.NoError(t, err)
+	for _, w := range []*Writer{bw, fw} {
+		_, err = w.Write([]byte("123"))
+		require.NoError(t, err)
+		off, err := w.WriteEmptyN(n)
+		require.NoError(t, err)
+		require.Equal(t, int64(Block), off)
+		_, err = w.Write([]byte("456"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		require.Equal(t, int64((n+2)*Block), w.Written())
+	}
+	require.Equal(t, bw.CRC(), fw.CRC())
+	data, err := os.ReadFile(f.Name())
+	require.NoError(t, err)
+	require.Equal(t, buf.Bytes(), data)
+
+	w, err := NewWriter(bytes.NewBuffer(nil), ThingBin)
+	require.NoError(t, err)
+	w.SkipEmptyCRC = true
+	_, err = w.WriteEmptyN(4)
+	require.NoError(t, err)
+	require.Equal(t, ZeroCRC, w.CRC())
+	require.Equal(t, int64(4*Block), w.Written())
+}
+
+func TestWriterWriteZeros(t *testing.T) {
+	for _, c := range []struct {
+		pre, n int
+	}{
+		{0, 0}, {0, 3}, {0, 8}, {0, 100 * Block}, {3, 5}, {3, 2}, {3, 77}, {5, 70000*Block + 3},
+	} {
+		exp := bytes.NewBuffer(nil)
+		ew, err := NewWriter(exp, ThingBin)
+		require.NoError(t, err)
+		_, err = ew.Write(bytes.Repeat([]byte{1}, c.pre))
+		require.NoError(t, err)
+		_, err = ew.Write(make([]byte, c.n))
+		require.NoError(t, err)
+		require.NoError(t, ew.Close())
+
+		got := bytes.NewBuffer(nil)
+		w, err := NewWriter(got, ThingBin)
+		require.NoError(t, err)
+		_, err = w.Write(bytes.Repeat([]byte{1}, c.pre))
+		require.NoError(t, err)
+		require.NoError(t, w.WriteZeros(int64(c.n)))
+		require.Equal(t, int64(c.pre+c.n), w.Written())
+		require.NoError(t, w.Close())
+
+		require.Equal(t, exp.Bytes(), got.Bytes(), "%d+%d", c.pre, c.n)
+		require.Equal(t, ew.CRC(), w.CRC())
+	}
+
+	w, err := NewWriter(bytes.NewBuffer(nil), ThingBin)
+	require.NoError(t, err)
+	require.ErrorIs(t, w.WriteZeros(-1), ErrInvalidSize)
+	require.EqualValues(t, 0, w.Written())
+}
+
+func TestWriterAvailableBuffer(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf, NoKey)
+	require.NoError(t, err)
+	require.Equal(t, Block, w.Available())
+
+	for _, s := range []string{"ab", "cdef", "gh", "ijk"} {
+		b := w.AvailableBuffer()
+		require.Equal(t, w.Available(), cap(b))
+		b = append(b, s...)
+		_, err = w.Write(b)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 5, w.Available())
+	require.NoError(t, w.Close())
+	require.Equal(t, "abcdefghijk\x00\x00\x00\x00\x00", buf.String())
+}
+
+func TestWriterString(t *testing.T) {
+	const (
+		key     = ThingBin
+		encoded = "\x2c\xc3\x70\x31\x5e\xda\x12\x3c\x49\x28\x27\x6d\x9a\x62\x8e\x94\x95\x36\x9f\xa2\x0d\xd0\x04\xee"
+		decoded = "ROLF\x01\x00\x00\x00\x03\x4d\x75\x64\x3e\x20\x03\x00\x08\x00\x00\x00\x00\x00\x00\x00"
+	)
+	buf := bytes.NewBuffer(nil)
+	w, err := NewWriter(buf, key)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteByte(decoded[0]))
+	n, err := w.WriteString(decoded[1:11])
+	require.NoError(t, err)
+	require.Equal(t, 10, n)
+	n, err = io.WriteString(w, decoded[11:])
+	require.NoError(t, err)
+	require.Equal(t, len(decoded)-11, n)
+	require.NoError(t, w.Close())
+	require.Equal(t, encoded, buf.String())
+
+	require.ErrorIs(t, w.WriteByte(0), ErrClosed)
+	_, err = w.WriteString("a")
+	require.ErrorIs(t, err, ErrClosed)
+}
+
+func TestWriterReadFrom(t *testing.T) {
+	data := make([]byte, 3*copyChunk+5*Block+3)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	for _, noZero := range []bool{false, true} {
+		exp := bytes.NewBuffer(nil)
+		ew, err := NewWriter(exp, ThingBin)
+		require.NoError(t, err)
+		ew.NoZero = noZero
+		for i := 0; i < len(data); i += 5 {
+			_, err = ew.Write(data[i:min(i+5, len(data))])
+			require.NoError(t, err)
+		}
+		require.NoError(t, ew.Close())
+
+		got := bytes.NewBuffer(nil)
+		w, err := NewWriter(got, ThingBin)
+		require.NoError(t, err)
+		w.NoZero = noZero
+		_, err = w.Write(data[:3])
+		require.NoError(t, err)
+		n, err := io.Copy(w, io.MultiReader(bytes.NewReader(data[3:])))
+		require.NoError(t, err)
+		require.EqualValues(t, len(data)-3, n)
+		require.EqualValues(t, len(data), w.Written())
+		require.NoError(t, w.Close())
+		require.Equal(t, exp.Bytes(), got.Bytes())
+		require.Equal(t, ew.CRC(), w.CRC())
+		require.Equal(t, ew.Stats().Blocks, w.Stats().Blocks)
+	}
+}
+
+func TestWriterBulk(t *testing.T) {
+	data := make([]byte, 2*copyChunk+16*Block)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	orig := bytes.Clone(data)
+	for _, key := range []Key{ThingBin, NoKey} {
+		for _, noZero := range []bool{false, true} {
+			exp := bytes.NewBuffer(nil)
+			ew, err := NewWriter(exp, key)
+			require.NoError(t, err)
+			ew.NoZero = noZero
+			for i := 0; i < len(data); i += 5 {
+				_, err = ew.Write(data[i:min(i+5, len(data))])
+				require.NoError(t, err)
+			}
+			_, err = ew.Write([]byte{1, 2, 3})
+			require.NoError(t, err)
+			require.NoError(t, ew.Close())
+
+			got := bytes.NewBuffer(nil)
+			w, err := NewWriter(got, key)
+			require.NoError(t, err)
+			w.NoZero = noZero
+			n, err := w.Write(data)
+			require.NoError(t, err)
+			require.Equal(t, len(data), n)
+			require.Equal(t, 0, w.Pending())
+			_, err = w.Write([]byte{1, 2, 3})
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+			require.Equal(t, exp.Bytes(), got.Bytes())
+			require.Equal(t, ew.CRC(), w.CRC())
+			require.Equal(t, orig, data)
+		}
+	}
+}
+
+type failOnceWriter struct {
+	bytes.Buffer
+	failed bool
+}
+
+func (w *failOnceWriter) Write(p []byte) (int, error) {
+	if !w.failed {
+		w.failed = true
+		return 0, os.ErrClosed
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestWriterStickyError(t *testing.T) {
+	dst := &failOnceWriter{}
+	w, err := NewWriter(dst, ThingBin)
+	require.NoError(t, err)
+	require.NoError(t, w.Err())
+	_, err = w.Write(make([]byte, Block))
+	require.ErrorIs(t, err, os.ErrClosed)
+	require.ErrorIs(t, w.Err(), os.ErrClosed)
+
+	_, err = w.Write(make([]byte, Block))
+	require.ErrorIs(t, err, os.ErrClosed)
+	require.ErrorIs(t, w.Flush(), os.ErrClosed)
+	require.ErrorIs(t, w.Close(), os.ErrClosed)
+	require.Equal(t, 0, dst.Len())
+
+	w.Reset(dst)
+	require.NoError(t, w.Err())
+	_, err = w.Write(make([]byte, Block))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.Equal(t, Block, dst.Len())
+}
+
+func TestWriterBuffered(t *testing.T) {
+	w, err := NewWriter(bytes.NewBuffer(nil), NoKey)
+	require.NoError(t, err)
+	require.Equal(t, 0, w.Buffered())
+	require.Equal(t, 0, w.FlushPadding())
+	require.NoError(t, w.WriteU16(1))
+	require.Equal(t, 2, w.Buffered())
+	require.Equal(t, Block-2, w.FlushPadding())
+	require.NoError(t, w.WriteU16(1))
+	require.NoError(t, w.WriteU32(1))
+	require.Equal(t, 0, w.Buffered())
+	require.Equal(t, 0, w.FlushPadding())
+	require.NoError(t, w.WriteU8(1))
+	require.Equal(t, Block-1, w.FlushPadding())
+	require.NoError(t, w.Flush())
+	require.Equal(t, 0, w.FlushPadding())
+	require.EqualValues(t, 2*Block, w.Written())
+}
+
+func TestOpenAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.bin")
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, ThingBin)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("first part"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+
+	buf.Reset()
+	w.Reset(&buf)
+	_, err = w.Write([]byte("first part\x00\x00\x00\x00\x00\x00second"))
+	require.NoError(t, err)
+	require.NoError(t, w.Flush())
+	expCRC := w.CRC()
+
+	w, err = OpenAppend(path, ThingBin)
+	require.NoError(t, err)
+	require.EqualValues(t, 2*Block, w.Written())
+	_, err = w.Write([]byte("second"))
+	require.NoError(t, err)
+	require.NoError(t, w.Flush())
+	require.Equal(t, expCRC, w.CRC())
+	require.NoError(t, w.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, buf.Bytes(), data)
+
+	require.NoError(t, os.WriteFile(path, data[:5], 0644))
+	_, err = OpenAppend(path, ThingBin)
+	require.ErrorIs(t, err, ErrUnaligned)
+}
+
+func TestOpenAppendCipherCRC(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.bin")
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, ThingBin, WithCRCMode(CRCCipher))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("first block"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	expCRC := w.CRC()
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+
+	w, err = OpenAppend(path, ThingBin, WithCRCMode(CRCCipher))
+	require.NoError(t, err)
+	require.Equal(t, expCRC, w.CRC())
+	require.Equal(t, ZeroCRC, w.CipherCRC())
+	require.NoError(t, w.Close())
+}
+
+func TestWriterSeek(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "crypt-seek-")
+	require.NoError(t, err)
+	defer f.Close()
+
+	w, err := NewWriter(f, MapKey)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("block-1_block-2_block-3"))
+	require.NoError(t, err)
+
+	off, err := w.Seek(Block, io.SeekStart)
+	require.NoError(t, err)
+	require.EqualValues(t, Block, off)
+	_, err = w.Write([]byte("BLOCK-2_"))
+	require.NoError(t, err)
+	_, err = w.Seek(3, io.SeekCurrent)
+	require.ErrorIs(t, err, ErrUnaligned)
+	off, err = w.Seek(0, io.SeekEnd)
+	require.NoError(t, err)
+	require.EqualValues(t, 3*Block, off)
+	_, err = w.Write([]byte("tail"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	_, err = w.Seek(0, io.SeekStart)
+	require.ErrorIs(t, err, ErrClosed)
+
+	data, err := os.ReadFile(f.Name())
+	require.NoError(t, err)
+	require.NoError(t, Decode(data, MapKey))
+	require.Equal(t, "block-1_BLOCK-2_block-3\x00tail\x00\x00\x00\x00", string(data))
+
+	w, err = NewWriter(&bytes.Buffer{}, MapKey)
+	require.NoError(t, err)
+	_, err = w.Seek(0, io.SeekStart)
+	require.ErrorIs(t, err, errors.ErrUnsupported)
+}